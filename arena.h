@@ -0,0 +1,30 @@
+// Per-thread memory arenas for HashBatch.
+//
+// libargon2 allocates and frees the memory matrix on the calling thread, so
+// a goroutine locked to its OS thread can keep one arena across many hashes
+// instead of allocating and faulting in fresh pages for every password.
+// libargon2 wipes the matrix before handing it to the free callback.
+#include <stdlib.h>
+
+static __thread uint8_t *arena;
+static __thread size_t arena_size;
+
+int arena_allocate(uint8_t **memory, size_t bytes_to_allocate) {
+	if (bytes_to_allocate > arena_size) {
+		free(arena);
+		arena = malloc(bytes_to_allocate);
+		arena_size = arena != NULL ? bytes_to_allocate : 0;
+	}
+	*memory = arena;
+	return arena != NULL ? ARGON2_OK : ARGON2_MEMORY_ALLOCATION_ERROR;
+}
+
+void arena_free(uint8_t *memory, size_t bytes_to_allocate) {
+	// kept for the next hash on this thread; see arena_release
+}
+
+static void arena_release(void) {
+	free(arena);
+	arena = NULL;
+	arena_size = 0;
+}
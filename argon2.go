@@ -8,27 +8,12 @@ package argon2
 
 // #cgo CFLAGS: -I/usr/include
 // #cgo LDFLAGS: -L/usr/lib -largon2
-// #include <stdlib.h>
 // #include <argon2.h>
 import "C"
 
 import (
-	"bytes"
+	"context"
 	"crypto/subtle"
-	"strings"
-	"unsafe"
-)
-
-const (
-	ModeArgon2d  int = C.Argon2_d
-	ModeArgon2i  int = C.Argon2_i
-	ModeArgon2id int = C.Argon2_id
-)
-
-const (
-	Version10      int = C.ARGON2_VERSION_10
-	Version13      int = C.ARGON2_VERSION_13
-	VersionDefault int = C.ARGON2_VERSION_NUMBER
 )
 
 const (
@@ -42,110 +27,165 @@ const (
 // Hash hashes a password given a salt and an initialized Argon2 context. It
 // returns the calculated hash as an output of raw bytes.
 func Hash(ctx *Context, password, salt []byte) ([]byte, error) {
+	o := begin(context.Background(), "Hash")
+	hash, err := hashRaw(ctx, password, salt)
+	o.end(ctx, true, err)
+	return hash, err
+}
+
+func hashRaw(ctx *Context, password, salt []byte) ([]byte, error) {
 	if ctx == nil {
-		return nil, ErrContext
+		return nil, opError("Hash", ErrContext)
 	}
 
-	return ctx.hash(password, salt)
+	p, err := ctx.prepare(password)
+	if err != nil {
+		return nil, opError("Hash", err)
+	}
+	defer release(p, password)
+	hash, err := ctx.hash(p, salt)
+	return hash, opError("Hash", err)
 }
 
-// HashEncoded hashes a password and produces a crypt-like encoded string.
+// HashEncoded hashes a password and produces a crypt-like encoded string. As
+// with libargon2's argon2_hash, the secret, associated data and flags of ctx
+// are not used. The normalization of ctx, if any, is recorded in the result.
 func HashEncoded(ctx *Context, password []byte, salt []byte) (string, error) {
+	o := begin(context.Background(), "HashEncoded")
+	s, err := hashEncoded(ctx, password, salt)
+	o.end(ctx, true, err)
+	return s, err
+}
+
+func hashEncoded(ctx *Context, password []byte, salt []byte) (string, error) {
 	if ctx == nil {
-		return "", ErrContext
+		return "", opError("Hash", ErrContext)
 	}
 
 	if len(password) == 0 {
-		return "", ErrPassword
+		return "", opError("Hash", ErrPassword)
 	}
 	if len(salt) == 0 {
-		return "", ErrSalt
+		return "", opError("Hash", ErrSalt)
 	}
 
-	encodedlen := C.argon2_encodedlen(
-		C.uint32_t(ctx.Iterations),
-		C.uint32_t(ctx.Memory),
-		C.uint32_t(ctx.Parallelism),
-		C.uint32_t(len(salt)),
-		C.uint32_t(ctx.HashLen),
-		C.argon2_type(ctx.Mode))
-
-	s := make([]byte, encodedlen)
-
-	result := C.argon2_hash(
-		C.uint32_t(ctx.Iterations),
-		C.uint32_t(ctx.Memory),
-		C.uint32_t(ctx.Parallelism),
-		unsafe.Pointer(&password[0]), C.size_t(len(password)),
-		unsafe.Pointer(&salt[0]), C.size_t(len(salt)),
-		nil, C.size_t(ctx.HashLen),
-		(*C.char)(unsafe.Pointer(&s[0])), C.size_t(encodedlen),
-		C.argon2_type(ctx.Mode),
-		C.uint32_t(ctx.Version))
+	p, err := ctx.prepare(password)
+	if err != nil {
+		return "", opError("Hash", err)
+	}
+	defer release(p, password)
 
-	if result != C.ARGON2_OK {
-		return "", Error(result)
+	c := *ctx
+	c.Secret, c.KeyID, c.AssociatedData, c.Flags = nil, nil, nil, FlagDefault
+	hash, err := c.hash(p, salt)
+	if err != nil {
+		return "", opError("Hash", err)
 	}
 
-	// Strip trailing null byte(s)
-	s = bytes.TrimRight(s, "\x00")
-	return string(s), nil
+	e := &encoded{ctx: &c, salt: salt, hash: hash}
+	return e.String(), nil
 }
 
 // Verify verifies an Argon2 hash against a plaintext password.
 func Verify(ctx *Context, hash, password, salt []byte) (bool, error) {
+	o := begin(context.Background(), "Verify")
+	ok, err := verify(ctx, hash, password, salt)
+	o.end(ctx, ok, err)
+	return ok, err
+}
+
+func verify(ctx *Context, hash, password, salt []byte) (bool, error) {
 	if ctx == nil {
-		return false, ErrContext
+		return false, opError("Verify", ErrContext)
 	}
 	if len(hash) == 0 {
-		return false, ErrHash
+		return false, opError("Verify", ErrHash)
 	}
 
-	hash2, err := ctx.hash(password, salt)
+	p, err := ctx.prepare(password)
+	if err != nil {
+		return false, opError("Verify", err)
+	}
+	defer release(p, password)
+	hash2, err := ctx.hash(p, salt)
 	if err != nil {
-		return false, err
+		return false, opError("Verify", err)
 	}
 
 	return subtle.ConstantTimeCompare(hash, hash2) == 1, nil
 }
 
-// VerifyEncoded verifies an encoded Argon2 hash s against a plaintext password.
+// VerifyEncoded verifies an encoded Argon2 hash s against a plaintext
+// password. It reports false with a nil error if the password does not match.
+// Otherwise errors match ErrMalformedEncoding if s is malformed, or
+// ErrInvalidParams if the password is empty or the parameters in s cannot be
+// used, which includes memory above DefaultMaxMemory. Strings that record a
+// key identifier were hashed with a secret, and fail with ErrMissingSecret;
+// verify them with VerifyEncodedWith.
+//
+// The iterations and lanes in s are not bounded, so a single string can keep
+// a CPU busy for hours. Verify strings from untrusted sources, such as
+// clients or a database that may have been tampered with, with
+// Policy.VerifyEncoded instead.
 func VerifyEncoded(s string, password []byte) (bool, error) {
-	mode, err := getMode(s)
-
-	if err != nil {
-		return false, err
-	}
-	
-	cs := C.CString(s)
-	defer C.free(unsafe.Pointer(cs))
+	return VerifyEncodedWith(nil, s, password)
+}
 
-	result := C.argon2_verify(
-		cs,
-		unsafe.Pointer(&password[0]),
-		C.size_t(len(password)),
-		C.argon2_type(mode))
+// VerifyEncodedWith is like VerifyEncoded, but hashes with the secret,
+// associated data, flags and threads of ctx, which are not recorded in
+// encoded strings. All other parameters are taken from s. Use it to verify strings
+// produced by Encode from a Hash computed with a secret:
+//
+//	hash, err := argon2.Hash(ctx, password, salt)
+//	...
+//	s, err := argon2.Encode(ctx, salt, hash)
+//	...
+//	ok, err := argon2.VerifyEncodedWith(ctx, s, password)
+//
+// A nil ctx is equivalent to VerifyEncoded. If s records a key identifier
+// and ctx has no secret, it fails with ErrMissingSecret rather than reporting
+// a mismatch. Like VerifyEncoded, it does not bound iterations and lanes; see
+// Policy.VerifyEncodedWith for untrusted strings.
+func VerifyEncodedWith(ctx *Context, s string, password []byte) (bool, error) {
+	o := begin(context.Background(), "VerifyEncoded")
+	params, ok, err := verifyEncoded(ctx, nil, s, password)
+	o.end(params, ok, err)
+	return ok, err
+}
 
-	if result == C.ARGON2_OK {
-		return true, nil
-	} else if result == C.ARGON2_VERIFY_MISMATCH {
-		return false, nil
+// verifyEncoded implements VerifyEncodedWith, checking s against policy, or
+// the default policy if it is nil, first. It also returns the context decoded from s, or nil
+// if it was not decoded.
+func verifyEncoded(ctx *Context, policy *Policy, s string, password []byte) (*Context, bool, error) {
+	if len(password) == 0 {
+		return nil, false, opError("Verify", ErrPassword)
 	}
 
-	// argon2_verify always seems to return an error in this case...
-	return false, Error(result)
-}
+	e, err := decode(s)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(e.extra) > 0 {
+		return e.ctx, false, decodeError(e.extra[0].key)
+	}
+	if err := checkVerify(policy, e.ctx); err != nil {
+		return e.ctx, false, err
+	}
+	if len(e.ctx.KeyID) > 0 && (ctx == nil || ctx.Secret == nil) {
+		return e.ctx, false, opError("Verify", ErrMissingSecret)
+	}
+	if ctx != nil {
+		e.ctx.Secret = ctx.Secret
+		e.ctx.AssociatedData = ctx.AssociatedData
+		e.ctx.Flags = ctx.Flags
+		e.ctx.Threads = ctx.Threads
+	}
 
-// getMode tries to extract the mode from an Argon2 encoded string.
-func getMode(s string) (int, error) {
-	switch {
-	case strings.HasPrefix(s, "$argon2d"):
-		return ModeArgon2d, nil
-	case strings.HasPrefix(s, "$argon2id"):
-		return ModeArgon2id, nil
-	case strings.HasPrefix(s, "$argon2i"):
-		return ModeArgon2i, nil
-	default:
-		return -1, ErrDecodingFail
+	p, err := e.ctx.prepare(password)
+	if err != nil {
+		return e.ctx, false, opError("Verify", err)
 	}
+	defer release(p, password)
+	ok, err := e.verify(p)
+	return e.ctx, ok, opError("Verify", err)
 }
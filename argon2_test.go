@@ -3,6 +3,7 @@ package argon2
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"testing"
 )
 
@@ -151,23 +152,30 @@ func TestHashEncoded(t *testing.T) {
 func TestHash_Error(t *testing.T) {
 	ctx := NewContext()
 	_, err := Hash(ctx, []byte("password"), []byte("s"))
-	if err != ErrSaltTooShort {
+	if !errors.Is(err, ErrSaltTooShort) {
 		t.Errorf("got %q  want %q", err, ErrSaltTooShort)
 	}
 
 	ctx = NewContext()
 	ctx.Mode = 99
 	_, err = Hash(ctx, []byte("password"), []byte("somesalt"))
-	if err != ErrIncorrectType {
+	if !errors.Is(err, ErrIncorrectType) {
 		t.Errorf("got %q  want %q", err, ErrIncorrectType)
 	}
 
 	ctx = NewContext()
 	ctx.Memory = 4
 	_, err = Hash(ctx, []byte("password"), []byte("somesalt"))
-	if err != ErrMemoryTooLittle {
+	if !errors.Is(err, ErrMemoryTooLittle) {
 		t.Errorf("got %q  want %q", err, ErrMemoryTooLittle)
 	}
+
+	ctx = NewContext()
+	ctx.HashLen = 0
+	_, err = Hash(ctx, []byte("password"), []byte("somesalt"))
+	if !errors.Is(err, ErrOutputTooShort) {
+		t.Errorf("got %q  want %q", err, ErrOutputTooShort)
+	}
 }
 
 func TestVerify(t *testing.T) {
@@ -186,6 +194,103 @@ func TestVerifyEncoded(t *testing.T) {
 	testVerifyEncoded(t, ctx)
 }
 
+func TestVerifyEncoded_Error(t *testing.T) {
+	s, err := HashEncoded(NewContext(), []byte("password"), []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vectors := []struct {
+		s        string
+		password []byte
+		err      error
+	}{
+		{s, nil, ErrPassword},
+		{s, []byte{}, ErrPassword},
+		{s[:len(s)-1] + "!", []byte("password"), ErrDecodingFail},
+		{"$argon2i$v=19$m=4096,t=3$c29tZXNhbHQ$c29tZWhhc2g", []byte("password"), ErrDecodingFail},
+		{"$argon2i$v=19$m=1,t=3,p=1$c29tZXNhbHQ$c29tZWhhc2g", []byte("password"), ErrMemoryTooLittle},
+		{"$argon2i$v=19$m=4096,t=3,p=1$cw$c29tZWhhc2g", []byte("password"), ErrSaltTooShort},
+		// rejected before any memory is allocated
+		{"$argon2i$v=19$m=4294967295,t=1,p=1$c29tZXNhbHQ$c29tZWhhc2g", []byte("password"), ErrInvalidParams},
+		{"$argon2i$v=19$m=4194305,t=1,p=1$c29tZXNhbHQ$c29tZWhhc2g", []byte("password"), ErrInvalidParams},
+	}
+
+	for _, v := range vectors {
+		ok, err := VerifyEncoded(v.s, v.password)
+		if ok || !errors.Is(err, v.err) {
+			t.Errorf("VerifyEncoded(%q, %q) = %v, %v  want %v", v.s, v.password, ok, err, v.err)
+		}
+	}
+
+	huge := "$argon2i$v=19$m=4294967295,t=1,p=1$c29tZXNhbHQ$c29tZWhhc2g"
+	var perr *PolicyError
+	if _, err := VerifyEncoded(huge, []byte("password")); !errors.As(err, &perr) || perr.Param != "m" {
+		t.Errorf("VerifyEncoded(%q): got %v  want violation of m", huge, err)
+	}
+}
+
+func TestVerifyEncodedWith(t *testing.T) {
+	ctx := NewContext(ModeArgon2id)
+	ctx.Secret = []byte("somesecret")
+	ctx.AssociatedData = []byte("somedata")
+	password, salt := []byte("password"), []byte("somesalt")
+
+	hash, err := Hash(ctx, password, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Encode(ctx, salt, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := VerifyEncodedWith(ctx, s, password); err != nil || !ok {
+		t.Errorf("VerifyEncodedWith = %v, %v  want true", ok, err)
+	}
+	if ok, err := VerifyEncoded(s, password); err != nil || ok {
+		t.Errorf("VerifyEncoded without secret = %v, %v  want false", ok, err)
+	}
+
+	other := *ctx
+	other.Secret = []byte("othersecret")
+	if ok, err := VerifyEncodedWith(&other, s, password); err != nil || ok {
+		t.Errorf("VerifyEncodedWith other secret = %v, %v  want false", ok, err)
+	}
+}
+
+func TestThreads(t *testing.T) {
+	ctx := NewContext(ModeArgon2id)
+	ctx.Parallelism = 4
+	password, salt := []byte("password"), []byte("somesalt")
+
+	expected, err := Hash(ctx, password, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := HashEncoded(ctx, password, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the number of threads does not change the hash
+	for _, threads := range []int{1, 2, 8} {
+		ctx.Threads = threads
+		hash, err := Hash(ctx, password, salt)
+		if err != nil || !bytes.Equal(hash, expected) {
+			t.Errorf("threads=%d: got %x, %v  want %x", threads, hash, err, expected)
+		}
+		if ok, err := VerifyEncodedWith(&Context{Threads: threads}, s, password); err != nil || !ok {
+			t.Errorf("threads=%d: VerifyEncodedWith = %v, %v  want true", threads, ok, err)
+		}
+	}
+
+	ctx.Threads = -1
+	if _, err := Hash(ctx, password, salt); !errors.Is(err, ErrThreadsTooMany) {
+		t.Errorf("threads=-1: got %v  want %v", err, ErrThreadsTooMany)
+	}
+}
+
 func TestFlagClearPassword(t *testing.T) {
 	ctx := NewContext()
 	ctx.Flags = FlagDefault
@@ -0,0 +1,358 @@
+package argon2d
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tvdburgt/go-argon2"
+)
+
+// testServer starts s on a socket in a temporary directory, and returns the
+// socket path. The server is closed when the test ends.
+func testServer(t *testing.T, s *Server) string {
+	// socket paths are limited to about 100 bytes, so avoid t.TempDir
+	dir, err := ioutil.TempDir("", "argon2d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	if s.Params == nil {
+		s.Params = &argon2.Context{
+			Iterations:  1,
+			Memory:      64,
+			Parallelism: 1,
+			HashLen:     32,
+			Mode:        argon2.ModeArgon2id,
+		}
+	}
+	if s.ErrorLog == nil {
+		s.ErrorLog = log.New(ioutil.Discard, "", 0)
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(l) }()
+
+	t.Cleanup(func() {
+		s.Close()
+		if err := <-done; err != ErrServerClosed {
+			t.Errorf("Serve = %v  want ErrServerClosed", err)
+		}
+		os.RemoveAll(dir)
+	})
+	return path
+}
+
+func testClient(t *testing.T, path string) *Client {
+	c, err := Dial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func testKeyring(t *testing.T, ids ...string) *Keyring {
+	k := NewKeyring()
+	for _, id := range ids {
+		if err := k.Add(id, []byte("secret of "+id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(k.Destroy)
+	return k
+}
+
+func TestKeyring(t *testing.T) {
+	k := testKeyring(t, "k1")
+	secret := []byte("another secret")
+	if err := k.Add("k1", secret); err == nil {
+		t.Error("Add(duplicate id) = nil  want error")
+	}
+	for _, b := range secret {
+		if b != 0 {
+			t.Fatalf("secret not wiped: %q", secret)
+		}
+	}
+	if got, _ := k.key("k1"); string(got) != "secret of k1" {
+		t.Errorf("key(k1) = %q after duplicate Add", got)
+	}
+
+	if err := k.Add("toolongid", []byte("secret")); err == nil {
+		t.Error("Add(9-byte id) = nil  want error")
+	}
+	if err := k.SetCurrent("k2"); err == nil {
+		t.Error("SetCurrent(unknown id) = nil  want error")
+	}
+}
+
+func TestHashVerify(t *testing.T) {
+	keys := testKeyring(t, "k1")
+	path := testServer(t, &Server{Keyring: keys})
+	c := testClient(t, path)
+	ctx := context.Background()
+
+	s, err := c.Hash(ctx, []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(s, ",keyid=azE$") {
+		t.Errorf("Hash = %q  want keyid of k1", s)
+	}
+
+	ok, rehash, err := c.Verify(ctx, s, []byte("password"))
+	if err != nil || !ok || rehash {
+		t.Errorf("Verify = %v, %v, %v  want true, false, nil", ok, rehash, err)
+	}
+	ok, rehash, err = c.Verify(ctx, s, []byte("wrong"))
+	if err != nil || ok || rehash {
+		t.Errorf("Verify(wrong) = %v, %v, %v  want false, false, nil", ok, rehash, err)
+	}
+
+	// the hash depends on the secret, which the client does not know
+	if ok, err := argon2.VerifyEncoded(s, []byte("password")); ok || !errors.Is(err, argon2.ErrMissingSecret) {
+		t.Errorf("argon2.VerifyEncoded = %v, %v  want false, ErrMissingSecret", ok, err)
+	}
+
+	if rehash, err := c.NeedsRehash(ctx, s); err != nil || rehash {
+		t.Errorf("NeedsRehash = %v, %v  want false, nil", rehash, err)
+	}
+}
+
+func TestNoKeyring(t *testing.T) {
+	path := testServer(t, &Server{})
+	c := testClient(t, path)
+
+	s, err := c.Hash(context.Background(), []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := argon2.VerifyEncoded(s, []byte("password")); err != nil || !ok {
+		t.Errorf("argon2.VerifyEncoded(%q) = %v, %v  want true", s, ok, err)
+	}
+
+	// without a policy, memory is still bounded
+	huge := "$argon2id$v=19$m=4294967295,t=1,p=1$c29tZXNhbHQ$aGFzaA"
+	if _, _, err := c.Verify(context.Background(), huge, []byte("password")); !errors.Is(err, argon2.ErrInvalidParams) ||
+		!strings.Contains(err.Error(), "above maximum") {
+		t.Errorf("Verify(m=4294967295) = %v  want ErrInvalidParams", err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	keys := testKeyring(t, "k1", "k2")
+	s := &Server{Keyring: keys}
+	path := testServer(t, s)
+	c := testClient(t, path)
+	ctx := context.Background()
+
+	old, err := c.Hash(ctx, []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.SetCurrent("k2"); err != nil {
+		t.Fatal(err)
+	}
+
+	ok, rehash, err := c.Verify(ctx, old, []byte("password"))
+	if err != nil || !ok || !rehash {
+		t.Errorf("Verify(old) = %v, %v, %v  want true, true, nil", ok, rehash, err)
+	}
+	if rehash, err := c.NeedsRehash(ctx, old); err != nil || !rehash {
+		t.Errorf("NeedsRehash(old) = %v, %v  want true, nil", rehash, err)
+	}
+
+	fresh, err := c.Hash(ctx, []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, rehash, err = c.Verify(ctx, fresh, []byte("password"))
+	if err != nil || !ok || rehash {
+		t.Errorf("Verify(fresh) = %v, %v, %v  want true, false, nil", ok, rehash, err)
+	}
+
+	// hashes under a retired key can no longer be verified
+	retired := testKeyring(t, "k2")
+	s2 := &Server{Keyring: retired}
+	c2 := testClient(t, testServer(t, s2))
+	_, _, err = c2.Verify(ctx, old, []byte("password"))
+	if !errors.Is(err, argon2.ErrInvalidParams) {
+		t.Errorf("Verify(unknown key) = %v  want ErrInvalidParams", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	policy := &argon2.Policy{MaxMemory: 1 << 10}
+	path := testServer(t, &Server{Policy: policy})
+	c := testClient(t, path)
+	ctx := context.Background()
+
+	expensive, err := argon2.HashEncoded(&argon2.Context{
+		Iterations:  1,
+		Memory:      1 << 11,
+		Parallelism: 1,
+		HashLen:     32,
+		Mode:        argon2.ModeArgon2id,
+	}, []byte("password"), []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		hash string
+		want error
+	}{
+		{"policy", expensive, argon2.ErrInvalidParams},
+		{"malformed", "$argon2id$v=19$m=64,t=1$c29tZXNhbHQ$aGFzaA", argon2.ErrMalformedEncoding},
+		{"bcrypt", "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy", argon2.ErrMalformedEncoding},
+	}
+	for _, tt := range tests {
+		_, _, err := c.Verify(ctx, tt.hash, []byte("password"))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: Verify = %v  want %v", tt.name, err, tt.want)
+		}
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%s: Verify = %T  want *Error", tt.name, err)
+		}
+	}
+
+	// the connection remains usable after errors
+	if _, err := c.Hash(ctx, []byte("password")); err != nil {
+		t.Errorf("Hash after errors = %v", err)
+	}
+
+	_, err = c.Hash(ctx, nil)
+	if !errors.Is(err, argon2.ErrInvalidParams) {
+		t.Errorf("Hash(nil) = %v  want ErrInvalidParams", err)
+	}
+	if rehash, err := c.NeedsRehash(ctx, "not a hash"); err != nil || !rehash {
+		t.Errorf("NeedsRehash(malformed) = %v, %v  want true, nil", rehash, err)
+	}
+}
+
+func TestProtocolErrors(t *testing.T) {
+	path := testServer(t, &Server{})
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := writeMessage(conn, &request{Op: "shred"}); err != nil {
+		t.Fatal(err)
+	}
+	var resp response
+	if err := readMessage(conn, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Err == nil || resp.Err.Kind != KindInvalid {
+		t.Errorf("unknown op: error = %v  want kind %q", resp.Err, KindInvalid)
+	}
+
+	// an oversized message closes the connection
+	conn.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := readMessage(conn, &resp); err == nil {
+		t.Error("oversized message: connection still open")
+	}
+}
+
+// limitHasher wraps a Hasher and records the maximum number of calls in
+// progress at once.
+type limitHasher struct {
+	argon2.Hasher
+
+	mu       sync.Mutex
+	inflight int
+	max      int
+}
+
+func (h *limitHasher) Hash(ctx context.Context, params *argon2.Context, password, salt []byte) ([]byte, error) {
+	h.mu.Lock()
+	h.inflight++
+	if h.inflight > h.max {
+		h.max = h.inflight
+	}
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		h.inflight--
+		h.mu.Unlock()
+	}()
+	return h.Hasher.Hash(ctx, params, password, salt)
+}
+
+func TestPeerConcurrency(t *testing.T) {
+	h := &limitHasher{Hasher: &argon2.Fake{Latency: 20 * time.Millisecond}}
+	path := testServer(t, &Server{Hasher: h, PeerConcurrency: 2})
+
+	const clients = 6
+	var wg sync.WaitGroup
+	errs := make(chan error, clients)
+	for i := 0; i < clients; i++ {
+		c := testClient(t, path)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 3; j++ {
+				if _, err := c.Hash(context.Background(), []byte("password")); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// on Linux all clients are the same user and share a limit; elsewhere
+	// each connection is limited on its own
+	want := 2
+	if runtime.GOOS != "linux" {
+		want = clients
+	}
+	if h.max > want {
+		t.Errorf("%d hashes in progress  want at most %d", h.max, want)
+	}
+	if h.max < 2 {
+		t.Errorf("%d hashes in progress  want concurrency", h.max)
+	}
+}
+
+func TestClientContext(t *testing.T) {
+	h := &argon2.Fake{Latency: 200 * time.Millisecond}
+	path := testServer(t, &Server{Hasher: h})
+	c := testClient(t, path)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.Hash(ctx, []byte("password")); err != context.DeadlineExceeded {
+		t.Errorf("Hash = %v  want DeadlineExceeded", err)
+	}
+
+	// the client reconnects for the next request
+	if _, err := c.Hash(context.Background(), []byte("password")); err != nil {
+		t.Errorf("Hash after timeout = %v", err)
+	}
+}
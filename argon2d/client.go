@@ -0,0 +1,121 @@
+package argon2d
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// Client sends requests to a Server over a Unix socket. It uses one
+// connection at a time, so its requests are answered one after another; use
+// several Clients to have more in progress, up to the server's per-peer
+// limit. A Client is safe for concurrent use and reconnects after errors.
+type Client struct {
+	path string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// Dial connects to the server listening on the Unix socket at path.
+func Dial(path string) (*Client, error) {
+	c := &Client{path: path}
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	return c, nil
+}
+
+// Hash hashes password with the server's parameters and current key, and
+// returns the encoded string.
+func (c *Client) Hash(ctx context.Context, password []byte) (string, error) {
+	resp, err := c.call(ctx, &request{Op: OpHash, Password: password})
+	return resp.Hash, err
+}
+
+// Verify verifies password against the encoded hash. If the password
+// matches, rehash reports whether the hash should be replaced with a new one
+// from Hash, because the server's parameters or current key have changed.
+func (c *Client) Verify(ctx context.Context, hash string, password []byte) (ok, rehash bool, err error) {
+	resp, err := c.call(ctx, &request{Op: OpVerify, Hash: hash, Password: password})
+	return resp.OK, resp.Rehash, err
+}
+
+// NeedsRehash reports whether the encoded hash was computed with parameters
+// or a key other than the server's current ones.
+func (c *Client) NeedsRehash(ctx context.Context, hash string) (bool, error) {
+	resp, err := c.call(ctx, &request{Op: OpNeedsRehash, Hash: hash})
+	return resp.Rehash, err
+}
+
+// Close closes the connection to the server.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+func (c *Client) call(ctx context.Context, req *request) (response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return response{}, err
+	}
+	if c.conn == nil {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "unix", c.path)
+		if err != nil {
+			return response{}, err
+		}
+		c.conn = conn
+	}
+
+	// interrupt the exchange once ctx is done, so that ctx.Err is set by
+	// the time the exchange fails
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case <-ctx.Done():
+			c.conn.SetDeadline(time.Unix(1, 0))
+		case <-stop:
+		}
+	}()
+
+	var resp response
+	err := writeMessage(c.conn, req)
+	if err == nil {
+		err = readMessage(c.conn, &resp)
+	}
+	close(stop)
+	<-done
+
+	if err == nil {
+		// ctx may have been done after the exchange, and the connection
+		// is kept for the next call
+		err = c.conn.SetDeadline(time.Time{})
+	}
+	if err != nil {
+		// the connection is out of step with the server
+		c.conn.Close()
+		c.conn = nil
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return response{}, ctxErr
+		}
+		return response{}, err
+	}
+	if resp.Err != nil {
+		return response{}, resp.Err
+	}
+	return resp, nil
+}
@@ -0,0 +1,95 @@
+package argon2d
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/tvdburgt/go-argon2"
+)
+
+// Keyring holds the secrets mixed into hashes, each under a short
+// identifier that is recorded in the hashes it produced. New hashes use the
+// current key; other keys are kept to verify existing hashes until they are
+// rehashed. Secrets are held in locked memory. A Keyring is safe for
+// concurrent use.
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    map[string]*argon2.SecretBytes
+	current string
+}
+
+// NewKeyring returns an empty Keyring.
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[string]*argon2.SecretBytes)}
+}
+
+// Add adds secret under id, which must be 1 to argon2.MaxKeyIDLen bytes long and
+// not in use, and wipes secret. The first key added becomes the current key. Keys are
+// never replaced, as requests in progress may be hashing with them.
+func (k *Keyring) Add(id string, secret []byte) error {
+	if len(id) == 0 || len(id) > argon2.MaxKeyIDLen {
+		return fmt.Errorf("argon2d: key id %q must be 1 to %d bytes", id, argon2.MaxKeyIDLen)
+	}
+	if len(secret) == 0 {
+		return errors.New("argon2d: empty secret")
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; ok {
+		zero(secret)
+		return fmt.Errorf("argon2d: duplicate key id %q", id)
+	}
+	s, err := argon2.SecretBytesFrom(secret)
+	if err != nil {
+		return err
+	}
+	k.keys[id] = s
+	if k.current == "" {
+		k.current = id
+	}
+	return nil
+}
+
+// SetCurrent makes the key id the one used for new hashes.
+func (k *Keyring) SetCurrent(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; !ok {
+		return fmt.Errorf("argon2d: unknown key id %q", id)
+	}
+	k.current = id
+	return nil
+}
+
+// Current returns the identifier of the current key, or "" if the keyring
+// is empty.
+func (k *Keyring) Current() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current
+}
+
+// Destroy wipes all secrets and empties the keyring.
+func (k *Keyring) Destroy() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for id, s := range k.keys {
+		s.Destroy()
+		delete(k.keys, id)
+	}
+	k.current = ""
+}
+
+// key returns the secret for id. Secrets stay valid until Destroy, which
+// must not be called while the server is running.
+func (k *Keyring) key(id string) ([]byte, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	s, ok := k.keys[id]
+	if !ok {
+		return nil, false
+	}
+	return s.Bytes(), true
+}
@@ -0,0 +1,34 @@
+//go:build linux
+// +build linux
+
+package argon2d
+
+import (
+	"net"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// peerID identifies the user at the other end of a Unix socket connection,
+// from the credentials the kernel recorded when it connected. It returns ""
+// if they are unavailable.
+func peerID(c net.Conn) string {
+	uc, ok := c.(*net.UnixConn)
+	if !ok {
+		return ""
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return ""
+	}
+
+	var cred *unix.Ucred
+	raw.Control(func(fd uintptr) {
+		cred, err = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err != nil || cred == nil {
+		return ""
+	}
+	return "uid:" + strconv.FormatUint(uint64(cred.Uid), 10)
+}
@@ -0,0 +1,136 @@
+// Package argon2d implements a hashing daemon and its client, for privilege
+// separation: passwords are hashed and verified by a separate process, which
+// alone holds the secrets ("peppers") mixed into the hashes. The command
+// github.com/tvdburgt/go-argon2/cmd/argon2d runs a Server.
+//
+// Client and Server talk over a Unix socket. Each message is a 4-byte
+// big-endian length followed by that many bytes of JSON. The client sends a
+// request and waits for its response before sending the next one:
+//
+//	{"op":"hash","password":"<base64>"}
+//	{"hash":"$argon2id$v=19$m=65536,t=3,p=4,keyid=...$...$..."}
+//
+//	{"op":"verify","hash":"$argon2id$...","password":"<base64>"}
+//	{"ok":true,"rehash":false}
+//
+//	{"op":"needs-rehash","hash":"$argon2id$..."}
+//	{"rehash":true}
+//
+// Failed requests are answered with {"error":{"kind":...,"message":...}}.
+package argon2d
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tvdburgt/go-argon2"
+)
+
+// Operations.
+const (
+	OpHash        = "hash"
+	OpVerify      = "verify"
+	OpNeedsRehash = "needs-rehash"
+)
+
+// MaxMessageSize is the maximum length of a message, excluding its length
+// prefix.
+const MaxMessageSize = 64 << 10
+
+var errMessageSize = errors.New("argon2d: message too large")
+
+type request struct {
+	Op       string `json:"op"`
+	Password []byte `json:"password,omitempty"`
+	Hash     string `json:"hash,omitempty"`
+}
+
+type response struct {
+	Hash   string `json:"hash,omitempty"`
+	OK     bool   `json:"ok,omitempty"`
+	Rehash bool   `json:"rehash,omitempty"`
+	Err    *Error `json:"error,omitempty"`
+}
+
+// Error kinds, which correspond to the error categories of package argon2.
+const (
+	KindInvalid   = "invalid"   // argon2.ErrInvalidParams
+	KindMalformed = "malformed" // argon2.ErrMalformedEncoding
+	KindExhausted = "exhausted" // argon2.ErrResourceExhausted
+	KindInternal  = "internal"  // any other failure of the daemon
+)
+
+// Error is an error reported by the daemon. Errors of the kinds above match
+// the corresponding argon2 error category with errors.Is.
+type Error struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("argon2d: %s", e.Message)
+}
+
+// Is reports whether target is the argon2 error category of e.
+func (e *Error) Is(target error) bool {
+	switch e.Kind {
+	case KindInvalid:
+		return target == argon2.ErrInvalidParams
+	case KindMalformed:
+		return target == argon2.ErrMalformedEncoding
+	case KindExhausted:
+		return target == argon2.ErrResourceExhausted
+	}
+	return false
+}
+
+// writeMessage writes v as a length-prefixed JSON message.
+func writeMessage(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	defer zero(b)
+	if len(b) > MaxMessageSize {
+		return errMessageSize
+	}
+
+	buf := make([]byte, 4+len(b))
+	defer zero(buf)
+	binary.BigEndian.PutUint32(buf, uint32(len(b)))
+	copy(buf[4:], b)
+	_, err = w.Write(buf)
+	return err
+}
+
+// readMessage reads a length-prefixed JSON message into v. The message is
+// wiped after decoding, as it may contain a password.
+func readMessage(r io.Reader, v interface{}) error {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return err
+	}
+	n := binary.BigEndian.Uint32(prefix[:])
+	if n > MaxMessageSize {
+		return errMessageSize
+	}
+
+	b := make([]byte, n)
+	defer zero(b)
+	if _, err := io.ReadFull(r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
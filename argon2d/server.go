@@ -0,0 +1,324 @@
+package argon2d
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/tvdburgt/go-argon2"
+)
+
+// DefaultPeerConcurrency is the number of requests a peer may have in
+// progress if Server.PeerConcurrency is zero.
+const DefaultPeerConcurrency = 2
+
+// Server answers requests from clients on a Unix socket.
+type Server struct {
+	// Params holds the parameters of new hashes. Its Secret and KeyID are
+	// ignored in favor of the current key of Keyring.
+	Params *argon2.Context
+
+	// Keyring holds the secrets of new and existing hashes. If it is nil or
+	// empty, hashes are computed without a secret.
+	Keyring *Keyring
+
+	// Policy is enforced before verifying a hash. If it is nil or has no
+	// MaxMemory, memory is limited to argon2.DefaultMaxMemory.
+	Policy *argon2.Policy
+
+	// Hasher computes hashes; argon2.DefaultHasher if nil.
+	Hasher argon2.Hasher
+
+	// PeerConcurrency limits the number of requests in progress for each
+	// peer, that is each user on Linux and each connection elsewhere.
+	// Requests beyond the limit wait. DefaultPeerConcurrency if zero.
+	PeerConcurrency int
+
+	// ErrorLog receives connection errors; the standard logger if nil.
+	ErrorLog *log.Logger
+
+	mu        sync.Mutex
+	peers     map[string]*peer
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+// peer limits the requests in progress for one peer.
+type peer struct {
+	sem  chan struct{}
+	refs int
+}
+
+// ErrServerClosed is returned by Serve after Close.
+var ErrServerClosed = errors.New("argon2d: server closed")
+
+// Serve accepts connections on l and serves each in its own goroutine. It
+// returns ErrServerClosed after Close, or the error that stopped it from
+// accepting connections.
+func (s *Server) Serve(l net.Listener) error {
+	if s.Params == nil {
+		return errors.New("argon2d: server has no parameters")
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrServerClosed
+	}
+	s.init()
+	s.listeners[l] = struct{}{}
+	s.mu.Unlock()
+
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			delete(s.listeners, l)
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			c.Close()
+			return ErrServerClosed
+		}
+		s.conns[c] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+		go s.serveConn(c)
+	}
+}
+
+// Close stops all listeners, cancels requests that have not started
+// hashing, and closes all connections. It waits for requests in progress to
+// finish.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.init()
+	s.closed = true
+	s.cancel()
+	for l := range s.listeners {
+		l.Close()
+	}
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return nil
+}
+
+// init allocates the state of s. The caller must hold s.mu.
+func (s *Server) init() {
+	if s.peers != nil {
+		return
+	}
+	s.peers = make(map[string]*peer)
+	s.listeners = make(map[net.Listener]struct{})
+	s.conns = make(map[net.Conn]struct{})
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+}
+
+func (s *Server) serveConn(c net.Conn) {
+	id := peerID(c)
+	if id == "" {
+		id = fmt.Sprintf("conn:%p", c)
+	}
+	p := s.acquirePeer(id)
+
+	defer func() {
+		s.releasePeer(id)
+		c.Close()
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		s.wg.Done()
+	}()
+
+	for {
+		var req request
+		if err := readMessage(c, &req); err != nil {
+			if err != io.EOF && !s.isClosed() {
+				s.logf("argon2d: %s: %v", id, err)
+			}
+			return
+		}
+
+		var resp response
+		select {
+		case p.sem <- struct{}{}:
+			resp = s.handle(&req)
+			<-p.sem
+		case <-s.ctx.Done():
+			resp.Err = &Error{KindInternal, "server closed"}
+		}
+		zero(req.Password)
+
+		if err := writeMessage(c, &resp); err != nil {
+			if !s.isClosed() {
+				s.logf("argon2d: %s: %v", id, err)
+			}
+			return
+		}
+	}
+}
+
+func (s *Server) acquirePeer(id string) *peer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.peers[id]
+	if !ok {
+		n := s.PeerConcurrency
+		if n <= 0 {
+			n = DefaultPeerConcurrency
+		}
+		p = &peer{sem: make(chan struct{}, n)}
+		s.peers[id] = p
+	}
+	p.refs++
+	return p
+}
+
+func (s *Server) releasePeer(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.peers[id]
+	if p.refs--; p.refs == 0 {
+		delete(s.peers, id)
+	}
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+func (s *Server) handle(req *request) response {
+	var resp response
+	var err error
+	switch req.Op {
+	case OpHash:
+		resp.Hash, err = s.hash(req.Password)
+	case OpVerify:
+		resp.OK, resp.Rehash, err = s.verify(req.Hash, req.Password)
+	case OpNeedsRehash:
+		resp.Rehash = argon2.NeedsRehash(s.current(), req.Hash)
+	default:
+		err = &Error{KindInvalid, fmt.Sprintf("unknown operation %q", req.Op)}
+	}
+	if err != nil {
+		resp.Err = toError(err)
+	}
+	return resp
+}
+
+// current returns the parameters of new hashes, with the current key.
+func (s *Server) current() *argon2.Context {
+	params := *s.Params
+	params.Secret, params.KeyID = nil, nil
+	if s.Keyring != nil {
+		if id := s.Keyring.Current(); id != "" {
+			params.Secret, _ = s.Keyring.key(id)
+			params.KeyID = []byte(id)
+		}
+	}
+	// the secret belongs to the keyring
+	params.Flags &^= argon2.FlagClearSecret
+	return &params
+}
+
+func (s *Server) hasher() argon2.Hasher {
+	if s.Hasher != nil {
+		return s.Hasher
+	}
+	return argon2.DefaultHasher
+}
+
+func (s *Server) hash(password []byte) (string, error) {
+	params := s.current()
+	salt, err := params.NewSalt()
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := s.hasher().Hash(s.ctx, params, password, salt)
+	if err != nil {
+		return "", err
+	}
+	params.Secret = nil
+	return argon2.Encode(params, salt, hash)
+}
+
+func (s *Server) verify(encoded string, password []byte) (ok, rehash bool, err error) {
+	var policy argon2.Policy
+	if s.Policy != nil {
+		policy = *s.Policy
+	}
+	if policy.MaxMemory == 0 {
+		policy.MaxMemory = argon2.DefaultMaxMemory
+	}
+	if err := policy.Check(encoded); err != nil {
+		return false, false, err
+	}
+	params, salt, hash, err := argon2.Decode(encoded)
+	if err != nil {
+		return false, false, err
+	}
+	if len(params.KeyID) > 0 {
+		var found bool
+		if s.Keyring != nil {
+			params.Secret, found = s.Keyring.key(string(params.KeyID))
+		}
+		if !found {
+			return false, false, &Error{KindInvalid, fmt.Sprintf("unknown key id %q", params.KeyID)}
+		}
+	}
+	params.Threads = s.Params.Threads
+
+	ok, err = s.hasher().Verify(s.ctx, params, hash, password, salt)
+	if err != nil || !ok {
+		return false, false, err
+	}
+	return true, argon2.NeedsRehash(s.current(), encoded), nil
+}
+
+// toError converts err into an Error for the client. Errors outside the
+// argon2 categories are not described, as they may reveal daemon internals.
+func toError(err error) *Error {
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, argon2.ErrMalformedEncoding):
+		return &Error{KindMalformed, err.Error()}
+	case errors.Is(err, argon2.ErrInvalidParams):
+		return &Error{KindInvalid, err.Error()}
+	case errors.Is(err, argon2.ErrResourceExhausted):
+		return &Error{KindExhausted, err.Error()}
+	}
+	return &Error{KindInternal, "internal error"}
+}
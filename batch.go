@@ -0,0 +1,118 @@
+package argon2
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// Input is a password and salt to be hashed by HashBatch.
+type Input struct {
+	Password []byte
+	Salt     []byte
+}
+
+// Result is the hash of an Input, or the error that prevented it.
+type Result struct {
+	Hash []byte
+	Err  error
+}
+
+// DefaultBatchMemory is the default limit, in KiB, on the memory used by all
+// workers of HashBatch together.
+const DefaultBatchMemory = 1 << 22 // 4 GiB
+
+// BatchOptions configures the worker pool of HashBatchWith.
+type BatchOptions struct {
+	Workers   int // number of workers (the number of CPUs per thread of ctx if zero)
+	MaxMemory int // memory limit in KiB of all workers together (DefaultBatchMemory if zero)
+}
+
+// HashBatch hashes many passwords with the same context, as Hash would, and
+// returns their results in the order of inputs. The work is spread over as
+// many workers as there are CPUs per thread of ctx, and each worker
+// reuses its hash memory from one item to the next. Since every worker keeps
+// ctx.Memory KiB, there are no more workers than fit in DefaultBatchMemory.
+func HashBatch(ctx *Context, inputs []Input) []Result {
+	return HashBatchWith(ctx, inputs, nil)
+}
+
+// HashBatchWith is like HashBatch, but sizes its worker pool with opts, which
+// may be nil. There is always at least one worker, even if ctx.Memory
+// exceeds opts.MaxMemory.
+func HashBatchWith(ctx *Context, inputs []Input, opts *BatchOptions) []Result {
+	results := make([]Result, len(inputs))
+	if ctx == nil {
+		for i := range results {
+			results[i].Err = opError("Hash", ErrContext)
+		}
+		return results
+	}
+
+	workers := batchWorkers(ctx, len(inputs), opts)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			// the arena belongs to the OS thread, so stay on it
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+			defer releaseArena()
+
+			for i := range jobs {
+				o := begin(context.Background(), "HashBatch")
+				hash, err := ctx.hashBatchItem(inputs[i].Password, inputs[i].Salt)
+				o.end(ctx, true, err)
+				results[i] = Result{hash, err}
+			}
+		}()
+	}
+	for i := range inputs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// batchWorkers returns the number of workers with which to hash n inputs.
+func batchWorkers(ctx *Context, n int, opts *BatchOptions) int {
+	if opts == nil {
+		opts = &BatchOptions{}
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+		if t := ctx.threads(); t > 1 {
+			workers /= t
+		}
+	}
+	maxMemory := opts.MaxMemory
+	if maxMemory <= 0 {
+		maxMemory = DefaultBatchMemory
+	}
+	if ctx.Memory > 0 && workers > maxMemory/ctx.Memory {
+		workers = maxMemory / ctx.Memory
+	}
+	if workers > n {
+		workers = n
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
+
+func (ctx *Context) hashBatchItem(password, salt []byte) ([]byte, error) {
+	p, err := ctx.prepare(password)
+	if err != nil {
+		return nil, opError("Hash", err)
+	}
+	defer release(p, password)
+	hash, err := ctx.hashArena(p, salt)
+	return hash, opError("Hash", err)
+}
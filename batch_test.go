@@ -0,0 +1,88 @@
+package argon2
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestHashBatch(t *testing.T) {
+	ctx := NewContext(ModeArgon2id)
+	ctx.Parallelism = 2
+
+	inputs := make([]Input, 20)
+	for i := range inputs {
+		inputs[i] = Input{[]byte(fmt.Sprintf("password%d", i)), []byte("somesalt")}
+	}
+	inputs[7].Salt = []byte("s")
+	inputs[13].Password = nil
+
+	results := HashBatch(ctx, inputs)
+	if len(results) != len(inputs) {
+		t.Fatalf("got %d results  want %d", len(results), len(inputs))
+	}
+	for i, r := range results {
+		hash, err := Hash(ctx, inputs[i].Password, inputs[i].Salt)
+		if !bytes.Equal(r.Hash, hash) || (r.Err == nil) != (err == nil) {
+			t.Errorf("%d: got %x, %v  want %x, %v", i, r.Hash, r.Err, hash, err)
+		}
+	}
+	if !errors.Is(results[7].Err, ErrSaltTooShort) || !errors.Is(results[13].Err, ErrPassword) {
+		t.Errorf("got errors %v, %v", results[7].Err, results[13].Err)
+	}
+
+	// arenas are reused across memory sizes
+	small := *ctx
+	small.Memory = 64
+	for i, r := range HashBatch(&small, inputs[:4]) {
+		hash, _ := Hash(&small, inputs[i].Password, inputs[i].Salt)
+		if !bytes.Equal(r.Hash, hash) {
+			t.Errorf("small %d: got %x  want %x", i, r.Hash, hash)
+		}
+	}
+}
+
+func TestHashBatch_Error(t *testing.T) {
+	for _, r := range HashBatch(nil, make([]Input, 3)) {
+		if !errors.Is(r.Err, ErrContext) {
+			t.Errorf("got %v  want %v", r.Err, ErrContext)
+		}
+	}
+	if r := HashBatch(NewContext(), nil); len(r) != 0 {
+		t.Errorf("got %d results for no inputs", len(r))
+	}
+}
+
+func TestHashBatch_Workers(t *testing.T) {
+	ctx := NewContext(ModeArgon2id)
+	ctx.Memory = 1 << 20 // 1 GiB
+
+	vectors := []struct {
+		n    int
+		opts *BatchOptions
+		want int
+	}{
+		{100, &BatchOptions{Workers: 64}, 4},
+		{100, &BatchOptions{Workers: 64, MaxMemory: 3 << 20}, 3},
+		{100, &BatchOptions{Workers: 2, MaxMemory: 8 << 20}, 2},
+		{100, &BatchOptions{Workers: 8, MaxMemory: 1 << 10}, 1},
+		{2, &BatchOptions{Workers: 8, MaxMemory: 8 << 20}, 2},
+		{0, nil, 1},
+	}
+	for _, v := range vectors {
+		if got := batchWorkers(ctx, v.n, v.opts); got != v.want {
+			t.Errorf("batchWorkers(%d, %+v) = %d  want %d", v.n, v.opts, got, v.want)
+		}
+	}
+	if got := batchWorkers(ctx, 100, nil); got > DefaultBatchMemory/ctx.Memory {
+		t.Errorf("batchWorkers(100, nil) = %d  want at most %d", got, DefaultBatchMemory/ctx.Memory)
+	}
+
+	inputs := []Input{{[]byte("password"), []byte("somesalt")}}
+	small := NewContext(ModeArgon2id)
+	r := HashBatchWith(small, inputs, &BatchOptions{Workers: 1, MaxMemory: small.Memory})
+	if hash, _ := Hash(small, inputs[0].Password, inputs[0].Salt); r[0].Err != nil || !bytes.Equal(r[0].Hash, hash) {
+		t.Errorf("HashBatchWith = %x, %v  want %x", r[0].Hash, r[0].Err, hash)
+	}
+}
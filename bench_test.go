@@ -58,7 +58,7 @@ func BenchmarkHash_id_m20_p1(b *testing.B) { benchmarkHash(b, ModeArgon2id, 20,
 func BenchmarkHash_id_m20_p2(b *testing.B) { benchmarkHash(b, ModeArgon2id, 20, 2) }
 func BenchmarkHash_id_m20_p4(b *testing.B) { benchmarkHash(b, ModeArgon2id, 20, 4) }
 
-func benchmarkHash(b *testing.B, mode, memory, parallelism int) {
+func benchmarkHash(b *testing.B, mode Mode, memory, parallelism int) {
 	ctx := &Context{
 		Iterations:  1,
 		Memory:      1 << uint(memory),
@@ -75,3 +75,42 @@ func benchmarkHash(b *testing.B, mode, memory, parallelism int) {
 		}
 	}
 }
+
+func BenchmarkHashBatch_m12(b *testing.B)      { benchmarkHashBatch(b, 12, true) }
+func BenchmarkHashBatch_m12_loop(b *testing.B) { benchmarkHashBatch(b, 12, false) }
+func BenchmarkHashBatch_m15(b *testing.B)      { benchmarkHashBatch(b, 15, true) }
+func BenchmarkHashBatch_m15_loop(b *testing.B) { benchmarkHashBatch(b, 15, false) }
+
+// benchmarkHashBatch hashes 64 passwords per iteration, either with HashBatch
+// or with a loop calling Hash.
+func benchmarkHashBatch(b *testing.B, memory int, batch bool) {
+	ctx := &Context{
+		Iterations:  1,
+		Memory:      1 << uint(memory),
+		Parallelism: 1,
+		HashLen:     32,
+		Mode:        ModeArgon2id,
+	}
+	inputs := make([]Input, 64)
+	for i := range inputs {
+		inputs[i] = Input{password, salt}
+	}
+
+	b.SetBytes(int64(len(inputs)) * int64(ctx.Memory) << 10)
+
+	for n := 0; n < b.N; n++ {
+		if batch {
+			for _, r := range HashBatch(ctx, inputs) {
+				if r.Err != nil {
+					b.Error(r.Err)
+				}
+			}
+			continue
+		}
+		for _, in := range inputs {
+			if _, err := Hash(ctx, in.Password, in.Salt); err != nil {
+				b.Error(err)
+			}
+		}
+	}
+}
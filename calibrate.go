@@ -0,0 +1,51 @@
+package argon2
+
+import (
+	"time"
+)
+
+// Calibrate finds parameters for which hashing takes about target on this
+// machine, without exceeding maxMemory KiB. It starts from a copy of ctx and
+// keeps its mode, version, parallelism and hash length. Memory is preferred
+// over iterations: the result uses as much memory as the cap allows, and
+// only uses less if a single pass over maxMemory already exceeds target.
+func Calibrate(ctx *Context, target time.Duration, maxMemory int) (*Context, error) {
+	if ctx == nil {
+		return nil, ErrContext
+	}
+
+	c := *ctx
+	c.Iterations = 1
+	c.Memory = maxMemory
+
+	var d time.Duration
+	for {
+		var err error
+		if d, err = measure(&c); err != nil {
+			return nil, err
+		}
+		if d <= target || c.Memory/2 < 8*c.Parallelism {
+			break
+		}
+		c.Memory /= 2
+	}
+
+	// hashing time grows linearly with the number of iterations
+	if d > 0 && target > d {
+		c.Iterations = int(target / d)
+	}
+
+	return &c, nil
+}
+
+// measure returns the time it takes to compute a hash with ctx.
+func measure(ctx *Context) (time.Duration, error) {
+	password := []byte("password")
+	salt := make([]byte, 16)
+
+	start := time.Now()
+	if _, err := Hash(ctx, password, salt); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
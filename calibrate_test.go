@@ -0,0 +1,22 @@
+package argon2
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalibrate(t *testing.T) {
+	ctx := NewContext(ModeArgon2id)
+	ctx.Parallelism = 2
+
+	c, err := Calibrate(ctx, 10*time.Millisecond, 1<<12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Memory > 1<<12 || c.Memory < 16 || c.Iterations < 1 {
+		t.Errorf("Calibrate: got %+v", c)
+	}
+	if c.Mode != ModeArgon2id || c.Parallelism != 2 || c.HashLen != ctx.HashLen {
+		t.Errorf("Calibrate changed fixed parameters: %+v", c)
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tvdburgt/go-argon2"
+)
+
+// audit reads stored hashes and reports those that do not meet the policy
+// given by its options, followed by a histogram of the schemes and Argon2
+// parameters found. Input is either one encoded hash per line, or an export
+// of user and hash columns as CSV or JSON lines ({"user": ..., "hash": ...}).
+// Rows are streamed through a bounded pool of workers, so arbitrarily large
+// exports are audited in constant memory.
+func audit(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("audit", "[file]", stderr)
+	types := fs.String("types", "", "comma-separated list of allowed Argon2 `types` (default any)")
+	format := fs.String("format", "lines", "input `format`: lines, csv or jsonl")
+	workers := fs.Int("workers", runtime.GOMAXPROCS(0), "`number` of concurrent workers")
+	var p argon2.Policy
+	fs.Var(&p.MinVersion, "v", "minimum `version`: 1.0 or 1.3")
+	fs.IntVar(&p.MinMemory, "k", 0, "minimum memory usage in `KiB`")
+	fs.IntVar(&p.MinIterations, "t", 0, "minimum number of `iterations`")
+	fs.IntVar(&p.MinHashLen, "l", 0, "minimum hash `length` in bytes")
+	fs.IntVar(&p.MinSaltLen, "s", 0, "minimum salt `length` in bytes")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("expected at most one file")
+	}
+	if *workers < 1 {
+		return fmt.Errorf("invalid number of workers %d", *workers)
+	}
+
+	if *types != "" {
+		for _, name := range strings.Split(*types, ",") {
+			mode, err := argon2.ParseMode(strings.TrimSpace(name))
+			if err != nil {
+				return err
+			}
+			p.Modes = append(p.Modes, mode)
+		}
+	}
+
+	var read func(io.Reader, chan<- row) error
+	switch *format {
+	case "lines":
+		read = readLines
+	case "csv":
+		read = readCSV
+	case "jsonl":
+		read = readJSONL
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	in := stdin
+	if fs.NArg() == 1 && fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	// Results are reported in input order, so results that arrive ahead of
+	// an earlier, slower row wait in pending. Rows are only handed to the
+	// workers while fewer than window rows are unreported, which bounds
+	// pending. Slots are taken in input order, so the row being waited for
+	// always holds one.
+	window := make(chan struct{}, 4**workers)
+	parsed := make(chan row)
+	rows := make(chan row, *workers)
+	results := make(chan result, *workers)
+
+	var readErr error
+	go func() {
+		readErr = read(in, parsed)
+		close(parsed)
+	}()
+	go func() {
+		for r := range parsed {
+			window <- struct{}{}
+			rows <- r
+		}
+		close(rows)
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range rows {
+				results <- check(&p, r)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var total, failed int
+	histogram := make(map[string]int)
+	pending := make(map[int]result)
+	next := 0
+	for res := range results {
+		pending[res.seq] = res
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-window
+
+			total++
+			histogram[r.class]++
+			if r.err != nil {
+				failed++
+				fmt.Fprintf(stdout, "%s: %s\n", r.user, strings.TrimPrefix(r.err.Error(), "argon2: "))
+			}
+		}
+	}
+	if readErr != nil {
+		return readErr
+	}
+
+	printHistogram(stdout, histogram)
+	fmt.Fprintf(stdout, "%d hashes, %d below policy\n", total, failed)
+	if failed > 0 {
+		return failure(fmt.Sprintf("%d hashes below policy", failed))
+	}
+	return nil
+}
+
+// row is a single stored hash read from the input.
+type row struct {
+	seq  int
+	user string
+	hash string
+	err  error // set if the row itself could not be parsed
+}
+
+// result is the outcome of checking a row.
+type result struct {
+	seq   int
+	user  string
+	class string
+	err   error
+}
+
+// check classifies the hash in r and checks it against the policy.
+func check(p *argon2.Policy, r row) result {
+	res := result{seq: r.seq, user: r.user, err: r.err}
+	if r.err != nil {
+		res.class = "malformed row"
+		return res
+	}
+
+	res.class = classify(r.hash)
+	s, err := argon2.Unwrap(r.hash)
+	if err != nil {
+		res.err = fmt.Errorf("not an Argon2 hash")
+		return res
+	}
+	res.err = p.Check(s)
+	return res
+}
+
+// legacySchemes maps prefixes of legacy hashes to scheme names.
+var legacySchemes = []struct {
+	prefix, name string
+}{
+	{"$2a$", "bcrypt"},
+	{"$2b$", "bcrypt"},
+	{"$2y$", "bcrypt"},
+	{"$scrypt$", "scrypt"},
+	{"$pbkdf2$", "pbkdf2-sha1"},
+	{"$pbkdf2-sha256$", "pbkdf2-sha256"},
+	{"$pbkdf2-sha512$", "pbkdf2-sha512"},
+	{"$1$", "md5-crypt"},
+	{"$5$", "sha256-crypt"},
+	{"$6$", "sha512-crypt"},
+}
+
+var innerRegexp = regexp.MustCompile(`,inner=([a-z0-9]+)`)
+
+// classify returns the scheme of a stored hash and, for Argon2 hashes, its
+// parameters.
+func classify(s string) string {
+	if a, err := argon2.Unwrap(s); err == nil {
+		ctx, _, _, err := argon2.Decode(a)
+		if err == nil {
+			return fmt.Sprintf("%s v=%d m=%d,t=%d,p=%d",
+				ctx.Mode, ctx.Version, ctx.Memory, ctx.Iterations, ctx.Parallelism)
+		}
+		if m := innerRegexp.FindStringSubmatch(a); m != nil {
+			return "argon2 wrapping " + m[1]
+		}
+		return "malformed argon2"
+	}
+
+	for _, l := range legacySchemes {
+		if strings.HasPrefix(s, l.prefix) {
+			return l.name
+		}
+	}
+	if _, err := hex.DecodeString(s); err == nil {
+		switch len(s) {
+		case 32:
+			return "hex digest (md5)"
+		case 40:
+			return "hex digest (sha1)"
+		}
+	}
+	return "unknown"
+}
+
+// printHistogram prints the number of hashes per class, most frequent first.
+func printHistogram(w io.Writer, histogram map[string]int) {
+	classes := make([]string, 0, len(histogram))
+	for class := range histogram {
+		classes = append(classes, class)
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		a, b := classes[i], classes[j]
+		if histogram[a] != histogram[b] {
+			return histogram[a] > histogram[b]
+		}
+		return a < b
+	})
+
+	width := 0
+	if len(classes) > 0 {
+		width = len(strconv.Itoa(histogram[classes[0]]))
+	}
+	for _, class := range classes {
+		fmt.Fprintf(w, "%*d  %s\n", width, histogram[class], class)
+	}
+}
+
+// readLines reads one encoded hash per line, identifying rows by line number.
+// Empty lines are skipped.
+func readLines(r io.Reader, rows chan<- row) error {
+	scanner := bufio.NewScanner(r)
+	seq := 0
+	for line := 1; scanner.Scan(); line++ {
+		s := strings.TrimSpace(scanner.Text())
+		if s == "" {
+			continue
+		}
+		rows <- row{seq: seq, user: strconv.Itoa(line), hash: s}
+		seq++
+	}
+	return scanner.Err()
+}
+
+// readCSV reads rows of user and hash columns. A header row naming these
+// columns is skipped.
+func readCSV(r io.Reader, rows chan<- row) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	seq := 0
+	for line := 1; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if _, ok := err.(*csv.ParseError); ok {
+			rows <- row{seq: seq, user: "line " + strconv.Itoa(line), err: err}
+			seq++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(record) < 2 {
+			rows <- row{seq: seq, user: "line " + strconv.Itoa(line), err: fmt.Errorf("expected user and hash columns")}
+			seq++
+			continue
+		}
+		if line == 1 && strings.EqualFold(record[0], "user") && strings.EqualFold(record[1], "hash") {
+			continue
+		}
+		rows <- row{seq: seq, user: record[0], hash: strings.TrimSpace(record[1])}
+		seq++
+	}
+}
+
+// readJSONL reads one JSON object with user and hash fields per line.
+func readJSONL(r io.Reader, rows chan<- row) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	seq := 0
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var v struct {
+			User string `json:"user"`
+			Hash string `json:"hash"`
+		}
+		r := row{seq: seq}
+		if err := json.Unmarshal(scanner.Bytes(), &v); err != nil {
+			r.user, r.err = "line "+strconv.Itoa(line), err
+		} else {
+			r.user, r.hash = v.User, v.Hash
+		}
+		rows <- r
+		seq++
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/tvdburgt/go-argon2"
+)
+
+// calibrate finds parameters for which hashing takes about the target time on
+// this machine, using argon2.Calibrate.
+func calibrate(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("calibrate", "", stderr)
+	mode := argon2.ModeArgon2id
+	fs.Var(&mode, "type", "Argon2 `type`: argon2i, argon2d or argon2id")
+	target := fs.Duration("time", 500*time.Millisecond, "target hashing `time`")
+	memory := fs.Int("k", 1<<16, "maximum memory usage in `KiB`")
+	parallelism := fs.Int("p", defaultThreads, "degree of `parallelism`")
+	hashLen := fs.Int("l", defaultHashLen, "hash output `length` in bytes")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	ctx := &argon2.Context{
+		Parallelism: *parallelism,
+		HashLen:     *hashLen,
+		Mode:        mode,
+		Version:     argon2.VersionDefault,
+	}
+	ctx, err := argon2.Calibrate(ctx, *target, *memory)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	if _, err := argon2.Hash(ctx, []byte("password"), make([]byte, 16)); err != nil {
+		return err
+	}
+	elapsed := time.Since(start)
+
+	printParams(stdout, ctx)
+	fmt.Fprintf(stdout, "Parameters:\tm=%d,t=%d,p=%d\n", ctx.Memory, ctx.Iterations, ctx.Parallelism)
+	fmt.Fprintf(stdout, "%2.3f seconds\n", elapsed.Seconds())
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tvdburgt/go-argon2"
+)
+
+// minSaltLen is the minimum salt length of libargon2 (ARGON2_MIN_SALT_LENGTH).
+const minSaltLen = 8
+
+// A command implements a subcommand. It returns a failure for a negative
+// outcome, or any other error if it could not complete.
+type command func(args []string, stdin io.Reader, stdout, stderr io.Writer) error
+
+// commands maps subcommand names to their implementations. Names shorter
+// than minSaltLen are never a salt that the reference utility would accept.
+// Longer names, such as calibrate, shadow a salt, so run treats them as a salt
+// when they follow "--".
+var commands = map[string]command{
+	"verify":    verify,
+	"inspect":   inspect,
+	"calibrate": calibrate,
+	"tune":      calibrate,
+	"audit":     audit,
+}
+
+// failure is the error returned by commands for a negative outcome.
+type failure string
+
+func (f failure) Error() string {
+	return string(f)
+}
+
+// runCommand runs a subcommand and returns its exit status.
+func runCommand(cmd command, name string, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	err := cmd(args, stdin, stdout, stderr)
+	switch err.(type) {
+	case nil:
+		return 0
+	case failure:
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return 1
+	}
+	if err == flag.ErrHelp {
+		return 0
+	}
+	fmt.Fprintf(stderr, "Error: %s: %s\n", name, strings.TrimPrefix(err.Error(), "argon2: "))
+	return 2
+}
+
+// newFlagSet returns a flag set for a subcommand that reports errors to
+// stderr.
+func newFlagSet(name, args string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage:  argon2 %s [options] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// printParams prints the parameters of ctx in the format of the reference
+// utility.
+func printParams(w io.Writer, ctx *argon2.Context) {
+	fmt.Fprintf(w, "Type:\t\t%s\n", typeNames[ctx.Mode])
+	fmt.Fprintf(w, "Iterations:\t%d\n", ctx.Iterations)
+	fmt.Fprintf(w, "Memory:\t\t%d KiB\n", ctx.Memory)
+	fmt.Fprintf(w, "Parallelism:\t%d\n", ctx.Parallelism)
+}
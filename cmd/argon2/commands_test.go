@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const encoded = "$argon2i$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$wWKIMhR9lyDFvRz9YTZweHKfbftvj+qf+YFY4NeBbtA"
+
+func runArgs(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	status := run(append([]string{"argon2"}, args...), strings.NewReader(stdin), &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+func TestVerify(t *testing.T) {
+	status, stdout, _ := runArgs("password", "verify", encoded)
+	if status != 0 || stdout != "Verification ok\n" {
+		t.Errorf("verify: got %d, %q", status, stdout)
+	}
+
+	status, _, stderr := runArgs("password", "verify", "argon2"+encoded)
+	if status != 0 {
+		t.Errorf("verify (Django): got %d, %q", status, stderr)
+	}
+
+	status, _, stderr = runArgs("wrong", "verify", encoded)
+	if status != 1 || stderr != "Error: The password does not match the supplied hash\n" {
+		t.Errorf("verify (mismatch): got %d, %q", status, stderr)
+	}
+
+	status, _, _ = runArgs("password", "verify", "$argon2i$v=19$m=65536")
+	if status != 2 {
+		t.Errorf("verify (malformed): got %d  want 2", status)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	status, stdout, stderr := runArgs("", "inspect", encoded)
+	if status != 0 {
+		t.Fatalf("inspect: got %d, %q", status, stderr)
+	}
+
+	expected := "Type:\t\tArgon2i\n" +
+		"Iterations:\t2\n" +
+		"Memory:\t\t65536 KiB\n" +
+		"Parallelism:\t1\n" +
+		"Version:\t13\n" +
+		"Hash length:\t32\n" +
+		"Salt:\t\t736f6d6573616c74\n"
+	if stdout != expected {
+		t.Errorf("got:\n%s\nwant:\n%s", stdout, expected)
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	for _, name := range []string{"calibrate", "tune"} {
+		status, stdout, stderr := runArgs("", name, "-time", "5ms", "-k", "1024", "-type", "argon2d")
+		if status != 0 {
+			t.Fatalf("%s: got %d, %q", name, status, stderr)
+		}
+		if !strings.HasPrefix(stdout, "Type:\t\tArgon2d\n") || !strings.Contains(stdout, "Parameters:\tm=") {
+			t.Errorf("%s: got %q", name, stdout)
+		}
+	}
+}
+
+func TestCommandNames(t *testing.T) {
+	// subcommand names of salt length are salts after --
+	for name := range commands {
+		if len(name) < minSaltLen {
+			continue
+		}
+		status, stdout, stderr := runArgs("password", "--", name, "-t", "2", "-e")
+		want := "$argon2i$v=19$m=4096,t=2,p=1$" + base64.RawStdEncoding.EncodeToString([]byte(name)) + "$"
+		if status != 0 || !strings.HasPrefix(stdout, want) {
+			t.Errorf("-- %s -t 2 -e: got %d, %q, %q  want prefix %q", name, status, stdout, stderr, want)
+		}
+	}
+
+	if status, _, _ := runArgs("password", "--"); status != exitMissingArgs {
+		t.Errorf("--: got status %d  want %d", status, exitMissingArgs)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "argon2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "hashes")
+	hashes := encoded + "\n" +
+		"$argon2id$v=19$m=4096,t=3,p=1$c29tZXNhbHQ$THaZx86KeqT+xuygENqvxaYIk3zu4wH0UmqzBL/wrdQ\n" +
+		"\n" +
+		"$2b$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy\n"
+	if err := ioutil.WriteFile(file, []byte(hashes), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	status, stdout, _ := runArgs("", "audit", "-k", "65536", file)
+	expected := "2: policy violation: m=4096 KiB below minimum 65536 KiB\n" +
+		"4: not an Argon2 hash\n" +
+		"1  argon2i v=19 m=65536,t=2,p=1\n" +
+		"1  argon2id v=19 m=4096,t=3,p=1\n" +
+		"1  bcrypt\n" +
+		"3 hashes, 2 below policy\n"
+	if status != 1 || stdout != expected {
+		t.Errorf("audit: got %d\n%s\nwant:\n%s", status, stdout, expected)
+	}
+
+	status, stdout, _ = runArgs(encoded, "audit", "-types", "argon2i", "-k", "65536")
+	if status != 0 || stdout != "1  argon2i v=19 m=65536,t=2,p=1\n1 hashes, 0 below policy\n" {
+		t.Errorf("audit (stdin): got %d, %q", status, stdout)
+	}
+
+	// hashes above DefaultMaxMemory meet a minimum-strength policy
+	huge := "$argon2id$v=19$m=8388608,t=3,p=1$c29tZXNhbHQ$Pb9OQA"
+	status, stdout, _ = runArgs(huge, "audit", "-k", "65536")
+	if status != 0 || stdout != "1  argon2id v=19 m=8388608,t=3,p=1\n1 hashes, 0 below policy\n" {
+		t.Errorf("audit (8 GiB): got %d, %q", status, stdout)
+	}
+}
+
+func TestAuditExport(t *testing.T) {
+	csv := "user,hash\n" +
+		"alice,\"" + encoded + "\"\n" +
+		"bob,$2b$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy\n" +
+		"carol,5f4dcc3b5aa765d61d8327deb882cf99\n" +
+		"dave\n"
+	status, stdout, _ := runArgs(csv, "audit", "-format", "csv", "-workers", "3", "-k", "65536")
+	expected := "bob: not an Argon2 hash\n" +
+		"carol: not an Argon2 hash\n" +
+		"line 5: expected user and hash columns\n" +
+		"1  argon2i v=19 m=65536,t=2,p=1\n" +
+		"1  bcrypt\n" +
+		"1  hex digest (md5)\n" +
+		"1  malformed row\n" +
+		"4 hashes, 3 below policy\n"
+	if status != 1 || stdout != expected {
+		t.Errorf("audit csv: got %d\n%s\nwant:\n%s", status, stdout, expected)
+	}
+
+	jsonl := `{"user": "alice", "hash": "` + encoded + `"}` + "\n" +
+		`{"user": "bob", "hash": "argon2` + encoded + `"}` + "\n"
+	status, stdout, _ = runArgs(jsonl, "audit", "-format", "jsonl", "-types", "argon2i")
+	expected = "2  argon2i v=19 m=65536,t=2,p=1\n" +
+		"2 hashes, 0 below policy\n"
+	if status != 0 || stdout != expected {
+		t.Errorf("audit jsonl: got %d\n%s\nwant:\n%s", status, stdout, expected)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tvdburgt/go-argon2"
+)
+
+// inspect prints the parameters of an encoded hash.
+func inspect(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("inspect", "encoded", stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected a single encoded hash")
+	}
+
+	s, err := argon2.Unwrap(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	ctx, salt, _, err := argon2.Decode(s)
+	if err != nil {
+		return err
+	}
+
+	printParams(stdout, ctx)
+	fmt.Fprintf(stdout, "Version:\t%x\n", int(ctx.Version))
+	fmt.Fprintf(stdout, "Hash length:\t%d\n", ctx.HashLen)
+	fmt.Fprintf(stdout, "Salt:\t\t%x\n", salt)
+	return nil
+}
@@ -0,0 +1,309 @@
+// Command argon2 is a drop-in replacement for the command-line utility that
+// ships with the reference Argon2 implementation. It accepts the same
+// arguments and produces the same output:
+//
+//	$ echo -n "password" | argon2 somesalt -t 2 -m 16
+//	Type:		Argon2i
+//	Iterations:	2
+//	Memory:		65536 KiB
+//	Parallelism:	1
+//	Hash:		c1628832147d9720c5bd1cfd61367078729f6dfb6f8fea9ff98158e0d7816ed0
+//	Encoded:	$argon2i$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$wWKIMhR9lyDFvRz9YTZweHKfbftvj+qf+YFY4NeBbtA
+//	0.188 seconds
+//	Verification ok
+//
+// The password is read from stdin.
+//
+// In addition, the first argument may name one of the following subcommands:
+//
+//	argon2 verify encoded           verify the password read from stdin
+//	argon2 inspect encoded          print the parameters of an encoded hash
+//	argon2 calibrate [options]      find parameters for a target hashing time
+//	argon2 audit [options] file     report encoded hashes below a policy
+//
+// tune is an alias of calibrate. Unlike the other names, calibrate is long
+// enough to be a salt; to hash with a salt that names a subcommand, put "--"
+// before it:
+//
+//	$ echo -n "password" | argon2 -- calibrate -t 2
+//
+// Run a subcommand with -h for its options. Subcommands exit with status 1 on
+// a negative outcome (a mismatch, or hashes below policy) and with status 2
+// on errors.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tvdburgt/go-argon2"
+)
+
+const (
+	defaultIterations = 3
+	defaultLogMemory  = 12 // 2^12 KiB = 4 MiB
+	defaultThreads    = 1
+	defaultHashLen    = 32
+	maxPasswordLen    = 128
+
+	maxMemoryBits = 32
+	maxLanes      = 0xFFFFFF
+)
+
+// exitMissingArgs is the exit status of the reference utility when it is
+// invoked without arguments (ARGON2_MISSING_ARGS as an unsigned byte).
+const exitMissingArgs = 226
+
+var typeNames = map[argon2.Mode]string{
+	argon2.ModeArgon2d:  "Argon2d",
+	argon2.ModeArgon2i:  "Argon2i",
+	argon2.ModeArgon2id: "Argon2id",
+}
+
+// errUsage signals that usage was printed on request.
+var errUsage = errors.New("usage")
+
+func main() {
+	os.Exit(run(os.Args, os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command and returns its exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) < 2 {
+		usage(stdout, args[0])
+		return exitMissingArgs
+	}
+
+	if args[1] == "--" {
+		// the salt follows, even if it names a subcommand
+		args = append([]string{args[0]}, args[2:]...)
+		if len(args) < 2 {
+			usage(stdout, args[0])
+			return exitMissingArgs
+		}
+	} else if cmd, ok := commands[args[1]]; ok {
+		return runCommand(cmd, args[1], args[2:], stdin, stdout, stderr)
+	}
+
+	err := hash(args, stdin, stdout)
+	if err == errUsage {
+		usage(stdout, args[0])
+		return 1
+	}
+	if err != nil {
+		// print library errors without the package prefix and operation,
+		// like libargon2
+		if oerr, ok := err.(*argon2.OpError); ok {
+			err = oerr.Err
+		}
+		fmt.Fprintf(stderr, "Error: %s\n", strings.TrimPrefix(err.Error(), "argon2: "))
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer, cmd string) {
+	fmt.Fprintf(w, "Usage:  %s [-h] salt [-i|-d|-id] [-t iterations] "+
+		"[-m log2(memory in KiB) | -k memory in KiB] [-p parallelism] "+
+		"[-l hash length] [-e|-r] [-v (10|13)]\n", cmd)
+	fmt.Fprintf(w, "\tPassword is read from stdin\n")
+	fmt.Fprintf(w, "Parameters:\n")
+	fmt.Fprintf(w, "\tsalt\t\tThe salt to use, at least 8 characters\n")
+	fmt.Fprintf(w, "\t-i\t\tUse Argon2i (this is the default)\n")
+	fmt.Fprintf(w, "\t-d\t\tUse Argon2d instead of Argon2i\n")
+	fmt.Fprintf(w, "\t-id\t\tUse Argon2id instead of Argon2i\n")
+	fmt.Fprintf(w, "\t-t N\t\tSets the number of iterations to N (default = %d)\n", defaultIterations)
+	fmt.Fprintf(w, "\t-m N\t\tSets the memory usage of 2^N KiB (default %d)\n", defaultLogMemory)
+	fmt.Fprintf(w, "\t-k N\t\tSets the memory usage of N KiB (default %d)\n", 1<<defaultLogMemory)
+	fmt.Fprintf(w, "\t-p N\t\tSets parallelism to N threads (default %d)\n", defaultThreads)
+	fmt.Fprintf(w, "\t-l N\t\tSets hash output length to N bytes (default %d)\n", defaultHashLen)
+	fmt.Fprintf(w, "\t-e\t\tOutput only encoded hash\n")
+	fmt.Fprintf(w, "\t-r\t\tOutput only the raw bytes of the hash\n")
+	fmt.Fprintf(w, "\t-v (10|13)\tArgon2 version (defaults to the most recent version, currently %x)\n", int(argon2.VersionDefault))
+	fmt.Fprintf(w, "\t-h\t\tPrint %s usage\n", cmd)
+}
+
+// hash implements the reference utility: it parses the salt and options from
+// args, hashes the password read from stdin and prints the result.
+func hash(args []string, stdin io.Reader, stdout io.Writer) error {
+	if args[1] == "-h" {
+		return errUsage
+	}
+
+	pwd, err := readPassword(stdin)
+	if err != nil {
+		return err
+	}
+	salt := []byte(args[1])
+
+	ctx := &argon2.Context{
+		Iterations:  defaultIterations,
+		Memory:      1 << defaultLogMemory,
+		Parallelism: defaultThreads,
+		HashLen:     defaultHashLen,
+		Mode:        argon2.ModeArgon2i,
+		Version:     argon2.VersionDefault,
+	}
+
+	var types int
+	var memorySet, encodedOnly, rawOnly bool
+	for i := 2; i < len(args); i++ {
+		a := args[i]
+
+		// options that take a numeric argument
+		switch a {
+		case "-m", "-k", "-t", "-p", "-l":
+			if i == len(args)-1 {
+				return fmt.Errorf("missing %s argument", a)
+			}
+			i++
+			input := strtoul(args[i])
+
+			switch a {
+			case "-m", "-k":
+				if memorySet {
+					return errors.New("-m or -k can only be used once")
+				}
+				memorySet = true
+				if input == 0 || input == maxUlong || (a == "-m" && input > maxMemoryBits) {
+					return fmt.Errorf("bad numeric input for %s", a)
+				}
+				if a == "-m" {
+					input = 1 << input
+				}
+				if input > 0xFFFFFFFF {
+					input = 0xFFFFFFFF
+				}
+				ctx.Memory = int(input)
+			case "-t":
+				if input == 0 || input == maxUlong || input > 0xFFFFFFFF {
+					return errors.New("bad numeric input for -t")
+				}
+				ctx.Iterations = int(input)
+			case "-p":
+				if input == 0 || input == maxUlong || input > maxLanes {
+					return errors.New("bad numeric input for -p")
+				}
+				ctx.Parallelism = int(input)
+			case "-l":
+				ctx.HashLen = int(uint32(input))
+			}
+			continue
+		}
+
+		switch a {
+		case "-h":
+			return errUsage
+		case "-i":
+			ctx.Mode = argon2.ModeArgon2i
+			types++
+		case "-d":
+			ctx.Mode = argon2.ModeArgon2d
+			types++
+		case "-id":
+			ctx.Mode = argon2.ModeArgon2id
+			types++
+		case "-e":
+			encodedOnly = true
+		case "-r":
+			rawOnly = true
+		case "-v":
+			if i == len(args)-1 {
+				return errors.New("missing -v argument")
+			}
+			i++
+			switch args[i] {
+			case "10":
+				ctx.Version = argon2.Version10
+			case "13":
+				ctx.Version = argon2.Version13
+			default:
+				return errors.New("invalid Argon2 version")
+			}
+		default:
+			return errors.New("unknown argument")
+		}
+	}
+
+	if types > 1 {
+		return errors.New("cannot specify multiple Argon2 types")
+	}
+	if encodedOnly && rawOnly {
+		return errors.New("cannot provide both -e and -r")
+	}
+
+	if !encodedOnly && !rawOnly {
+		printParams(stdout, ctx)
+	}
+
+	start := time.Now()
+	out, err := argon2.Hash(ctx, pwd, salt)
+	if err != nil {
+		return err
+	}
+	encoded, err := argon2.Encode(ctx, salt, out)
+	if err != nil {
+		return err
+	}
+	elapsed := time.Since(start)
+
+	if encodedOnly {
+		fmt.Fprintln(stdout, encoded)
+		return nil
+	}
+	if rawOnly {
+		fmt.Fprintf(stdout, "%x\n", out)
+		return nil
+	}
+
+	fmt.Fprintf(stdout, "Hash:\t\t%x\n", out)
+	fmt.Fprintf(stdout, "Encoded:\t%s\n", encoded)
+	fmt.Fprintf(stdout, "%2.3f seconds\n", elapsed.Seconds())
+
+	ok, err := argon2.VerifyEncoded(encoded, pwd)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return argon2.ErrVerifyMismatch
+	}
+	fmt.Fprintf(stdout, "Verification ok\n")
+	return nil
+}
+
+// readPassword reads the password from stdin as-is, including any trailing
+// newline.
+func readPassword(stdin io.Reader) ([]byte, error) {
+	pwd := make([]byte, maxPasswordLen)
+	n, err := io.ReadFull(stdin, pwd)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	if n < 1 {
+		return nil, errors.New("no password read")
+	}
+	if n == maxPasswordLen {
+		return nil, errors.New("Provided password longer than supported in command line utility")
+	}
+	return pwd[:n], nil
+}
+
+const maxUlong = 1<<64 - 1
+
+// strtoul parses the leading decimal digits of s like C's strtoul: it
+// returns 0 if there are none, and maxUlong on overflow.
+func strtoul(s string) uint64 {
+	var n uint64
+	for i := 0; i < len(s) && '0' <= s[i] && s[i] <= '9'; i++ {
+		d := uint64(s[i] - '0')
+		if n > (maxUlong-d)/10 {
+			return maxUlong
+		}
+		n = n*10 + d
+	}
+	return n
+}
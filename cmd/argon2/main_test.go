@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"argon2", "somesalt", "-t", "2", "-m", "16"},
+		strings.NewReader("password"), &stdout, &stderr)
+	if status != 0 {
+		t.Fatalf("exit status %d: %s", status, stderr.String())
+	}
+
+	// Output of the reference utility, modulo timing
+	expected := "Type:\t\tArgon2i\n" +
+		"Iterations:\t2\n" +
+		"Memory:\t\t65536 KiB\n" +
+		"Parallelism:\t1\n" +
+		"Hash:\t\tc1628832147d9720c5bd1cfd61367078729f6dfb6f8fea9ff98158e0d7816ed0\n" +
+		"Encoded:\t$argon2i$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$wWKIMhR9lyDFvRz9YTZweHKfbftvj+qf+YFY4NeBbtA\n" +
+		"0.000 seconds\n" +
+		"Verification ok\n"
+	got := regexp.MustCompile(`\d+\.\d{3} seconds`).ReplaceAllString(stdout.String(), "0.000 seconds")
+	if got != expected {
+		t.Errorf("got:\n%s\nwant:\n%s", got, expected)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	var stdout bytes.Buffer
+	usage(&stdout, "argon2")
+	if want := "(defaults to the most recent version, currently 13)\n"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("usage does not contain %q:\n%s", want, stdout.String())
+	}
+}
+
+func TestRun_Options(t *testing.T) {
+	vectors := []struct {
+		args   []string
+		status int
+		stdout string
+		stderr string
+	}{
+		{
+			[]string{"argon2", "somesalt", "-id", "-k", "4096", "-p", "2", "-e"}, 0,
+			"$argon2id$v=19$m=4096,t=3,p=2$c29tZXNhbHQ$", "",
+		},
+		{
+			[]string{"argon2", "somesalt", "-d", "-v", "10", "-l", "16", "-r"}, 0,
+			"", "",
+		},
+		{[]string{"argon2", "somesalt", "-d", "-i"}, 1, "", "Error: cannot specify multiple Argon2 types\n"},
+		{[]string{"argon2", "somesalt", "-e", "-r"}, 1, "", "Error: cannot provide both -e and -r\n"},
+		{[]string{"argon2", "somesalt", "-m", "12", "-k", "4096"}, 1, "", "Error: -m or -k can only be used once\n"},
+		{[]string{"argon2", "somesalt", "-t"}, 1, "", "Error: missing -t argument\n"},
+		{[]string{"argon2", "somesalt", "-v", "12"}, 1, "", "Error: invalid Argon2 version\n"},
+		{[]string{"argon2", "somesalt", "-x"}, 1, "", "Error: unknown argument\n"},
+		{[]string{"argon2", "salt", "-e"}, 1, "", "Error: Salt is too short\n"},
+		{[]string{"argon2", "-h"}, 1, "Usage:  argon2 [-h] salt", ""},
+		{[]string{"argon2"}, exitMissingArgs, "Usage:  argon2 [-h] salt", ""},
+	}
+
+	for _, v := range vectors {
+		var stdout, stderr bytes.Buffer
+		status := run(v.args, strings.NewReader("password"), &stdout, &stderr)
+		if status != v.status {
+			t.Errorf("%q: exit status %d  want %d", v.args, status, v.status)
+		}
+		if !strings.HasPrefix(stdout.String(), v.stdout) {
+			t.Errorf("%q: stdout %q  want prefix %q", v.args, stdout.String(), v.stdout)
+		}
+		if stderr.String() != v.stderr {
+			t.Errorf("%q: stderr %q  want %q", v.args, stderr.String(), v.stderr)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tvdburgt/go-argon2"
+)
+
+// verify verifies the password read from stdin against an encoded hash. The
+// hash may also be in one of the formats accepted by argon2.VerifyCompat.
+func verify(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("verify", "encoded", stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected a single encoded hash")
+	}
+
+	pwd, err := readPassword(stdin)
+	if err != nil {
+		return err
+	}
+
+	ok, err := argon2.VerifyCompat(fs.Arg(0), pwd)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return failure("The password does not match the supplied hash")
+	}
+
+	fmt.Fprintf(stdout, "Verification ok\n")
+	return nil
+}
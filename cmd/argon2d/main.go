@@ -0,0 +1,186 @@
+// Command argon2d runs a hashing daemon that hashes and verifies passwords on
+// behalf of other processes, which connect to it over a Unix socket with
+// package github.com/tvdburgt/go-argon2/argon2d. Only the daemon reads the
+// secrets mixed into the hashes:
+//
+//	$ argon2d -socket /run/argon2d.sock -params 'mode=argon2id,memory=64MiB' -keys /etc/argon2d/keys
+//
+// The keys file holds one key per line: an identifier of 1 to 8 bytes and the
+// base64-encoded secret, separated by whitespace. Blank lines and lines
+// starting with # are ignored. New hashes use the key named by -current, or
+// the first key in the file. To rotate keys, add a key, restart the daemon
+// with -current naming it, and remove the old key once no hashes use it.
+//
+// The daemon stops on SIGINT or SIGTERM, after finishing the requests in
+// progress.
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/tvdburgt/go-argon2"
+	"github.com/tvdburgt/go-argon2/argon2d"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("argon2d: ")
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("argon2d", flag.ContinueOnError)
+	socket := fs.String("socket", "argon2d.sock", "listen on the Unix socket at `path`")
+	perm := fs.String("perm", "0660", "permissions of the socket, in octal")
+	params := fs.String("params", "", "parameters of new hashes, as `key=value,...` (default argon2id with library defaults)")
+	keys := fs.String("keys", "", "read secrets from `file`")
+	current := fs.String("current", "", "use the key `id` for new hashes")
+	maxMemory := fs.Int("max-memory", 0, "reject hashes using more than `KiB` of memory (default the memory of -params)")
+	maxIterations := fs.Int("max-iterations", 0, "reject hashes with more than `n` iterations (default the iterations of -params)")
+	maxLanes := fs.Int("max-lanes", 0, "reject hashes with more than `n` lanes (default the parallelism of -params)")
+	peerConcurrency := fs.Int("peer-concurrency", argon2d.DefaultPeerConcurrency, "hash at most `n` passwords at once for each peer")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	mode, err := strconv.ParseUint(*perm, 8, 32)
+	if err != nil || mode > 0777 {
+		return fmt.Errorf("invalid -perm %q", *perm)
+	}
+
+	ctx := argon2.NewContext(argon2.ModeArgon2id)
+	if err := ctx.UnmarshalText([]byte(*params)); err != nil {
+		return err
+	}
+
+	// hashes made with earlier, cheaper parameters must still verify, so the
+	// policy only caps parameters at the current ones by default
+	policy := &argon2.Policy{
+		MaxMemory:     ctx.Memory,
+		MaxIterations: ctx.Iterations,
+		MaxLanes:      ctx.Parallelism,
+	}
+	if *maxMemory > 0 {
+		policy.MaxMemory = *maxMemory
+	}
+	if *maxIterations > 0 {
+		policy.MaxIterations = *maxIterations
+	}
+	if *maxLanes > 0 {
+		policy.MaxLanes = *maxLanes
+	}
+
+	keyring := argon2d.NewKeyring()
+	defer keyring.Destroy()
+	if *keys != "" {
+		f, err := os.Open(*keys)
+		if err != nil {
+			return err
+		}
+		err = loadKeys(keyring, f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", *keys, err)
+		}
+	}
+	if *current != "" {
+		if err := keyring.SetCurrent(*current); err != nil {
+			return err
+		}
+	}
+	if keyring.Current() == "" {
+		log.Print("no keys: hashing without a secret")
+	}
+
+	// a socket left behind by a daemon that did not shut down cleanly
+	// prevents listening
+	if fi, err := os.Lstat(*socket); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if c, err := net.Dial("unix", *socket); err == nil {
+			c.Close()
+			return fmt.Errorf("%s: already in use", *socket)
+		}
+		os.Remove(*socket)
+	}
+
+	// create the socket with its final permissions, so that it is never
+	// open to more users than intended
+	old := umask(0777 &^ int(mode))
+	l, err := net.Listen("unix", *socket)
+	umask(old)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(*socket, os.FileMode(mode)); err != nil {
+		l.Close()
+		return err
+	}
+
+	s := &argon2d.Server{
+		Params:          ctx,
+		Keyring:         keyring,
+		Policy:          policy,
+		PeerConcurrency: *peerConcurrency,
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sig
+		s.Close()
+	}()
+
+	log.Printf("listening on %s", *socket)
+	err = s.Serve(l)
+	if err == argon2d.ErrServerClosed {
+		err = nil
+	}
+	return err
+}
+
+// loadKeys adds the keys read from r to k. The first key read becomes the
+// current key.
+func loadKeys(k *argon2d.Keyring, r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	n := 0
+	for line := 1; sc.Scan(); line++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) != 2 {
+			return fmt.Errorf("line %d: want key id and secret", line)
+		}
+		secret, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil {
+			return fmt.Errorf("line %d: invalid secret: %v", line, err)
+		}
+		if err := k.Add(fields[0], secret); err != nil {
+			return fmt.Errorf("line %d: %v", line, err)
+		}
+		n++
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("no keys")
+	}
+	return nil
+}
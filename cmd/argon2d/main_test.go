@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tvdburgt/go-argon2/argon2d"
+)
+
+func TestLoadKeys(t *testing.T) {
+	tests := []struct {
+		in      string
+		current string
+		err     string
+	}{
+		{"k1 c2VjcmV0MQ==\nk2 c2VjcmV0Mg==\n", "k1", ""},
+		{"# rotated 2026-10\n\n  k2\tc2VjcmV0Mg==\n", "k2", ""},
+		{"", "", "no keys"},
+		{"k1\n", "", "line 1: want key id and secret"},
+		{"k1 c2VjcmV0MQ==\nk2 !!\n", "", "line 2: invalid secret"},
+		{"toolongid c2VjcmV0MQ==\n", "", "line 1: argon2d: key id"},
+		{"k1 c2VjcmV0MQ==\nk1 c2VjcmV0Mg==\n", "", "line 2: argon2d: duplicate key id"},
+	}
+	for _, tt := range tests {
+		k := argon2d.NewKeyring()
+		err := loadKeys(k, strings.NewReader(tt.in))
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("loadKeys(%q) = %v", tt.in, err)
+		case tt.err != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.err)):
+			t.Errorf("loadKeys(%q) = %v  want %q", tt.in, err, tt.err)
+		case tt.err == "" && k.Current() != tt.current:
+			t.Errorf("loadKeys(%q): current key %q  want %q", tt.in, k.Current(), tt.current)
+		}
+		k.Destroy()
+	}
+}
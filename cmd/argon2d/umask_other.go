@@ -0,0 +1,10 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package main
+
+// umask does nothing on this platform, where the socket only gets its
+// permissions from os.Chmod.
+func umask(mask int) int {
+	return 0
+}
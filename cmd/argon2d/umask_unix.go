@@ -0,0 +1,14 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package main
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// umask sets the file mode creation mask of the process and returns the
+// previous mask.
+func umask(mask int) int {
+	return unix.Umask(mask)
+}
@@ -0,0 +1,89 @@
+package argon2
+
+import (
+	"strings"
+)
+
+// Prefixes that password frameworks put in front of Argon2 encoded strings.
+// Passlib and argon2-cffi store plain encoded strings, which need no
+// conversion.
+const (
+	DjangoPrefix = "argon2"   // Django's Argon2PasswordHasher
+	SpringPrefix = "{argon2}" // Spring Security's DelegatingPasswordEncoder
+)
+
+// FromDjango converts a hash stored by Django's Argon2PasswordHasher, such as
+//
+//	argon2$argon2id$v=19$m=102400,t=2,p=8$<salt>$<hash>
+//
+// to an encoded string accepted by VerifyEncoded.
+func FromDjango(s string) (string, error) {
+	if !strings.HasPrefix(s, DjangoPrefix+"$argon2") {
+		return "", ErrDecodingFail
+	}
+	return s[len(DjangoPrefix):], nil
+}
+
+// ToDjango converts an encoded string to the format stored by Django's
+// Argon2PasswordHasher.
+func ToDjango(s string) (string, error) {
+	if _, err := decode(s); err != nil {
+		return "", err
+	}
+	return DjangoPrefix + s, nil
+}
+
+// FromSpring converts a hash stored by Spring Security's
+// DelegatingPasswordEncoder, such as
+//
+//	{argon2}$argon2id$v=19$m=16384,t=2,p=1$<salt>$<hash>
+//
+// to an encoded string accepted by VerifyEncoded. Versioned encoder ids, such
+// as {argon2@SpringSecurity_v5_8}, are accepted as well.
+func FromSpring(s string) (string, error) {
+	if !strings.HasPrefix(s, "{argon2") {
+		return "", ErrDecodingFail
+	}
+	i := strings.Index(s, "}")
+	if i < 0 || (i != len(SpringPrefix)-1 && s[len("{argon2")] != '@') {
+		return "", ErrDecodingFail
+	}
+	return s[i+1:], nil
+}
+
+// ToSpring converts an encoded string to the format stored by Spring
+// Security's DelegatingPasswordEncoder.
+func ToSpring(s string) (string, error) {
+	if _, err := decode(s); err != nil {
+		return "", err
+	}
+	return SpringPrefix + s, nil
+}
+
+// Unwrap converts a hash stored by any of the supported password frameworks,
+// or an LDAP userPassword value, to an encoded string accepted by
+// VerifyEncoded. Plain encoded strings are returned unchanged.
+func Unwrap(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, "$argon2"):
+		return s, nil
+	case strings.HasPrefix(s, DjangoPrefix+"$"):
+		return FromDjango(s)
+	case strings.HasPrefix(s, LDAPScheme):
+		return FromLDAP([]byte(s))
+	case strings.HasPrefix(s, "{"):
+		return FromSpring(s)
+	default:
+		return "", ErrDecodingFail
+	}
+}
+
+// VerifyCompat is like VerifyEncoded, but also accepts the hash formats of
+// Django, Spring Security, passlib and OpenLDAP.
+func VerifyCompat(s string, password []byte) (bool, error) {
+	s, err := Unwrap(s)
+	if err != nil {
+		return false, err
+	}
+	return VerifyEncoded(s, password)
+}
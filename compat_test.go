@@ -0,0 +1,82 @@
+package argon2
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCompat(t *testing.T) {
+	ctx := NewContext(ModeArgon2id)
+	s, err := HashEncoded(ctx, []byte("password"), []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	django, err := ToDjango(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(django, "argon2$argon2id$v=19$") {
+		t.Errorf("ToDjango(%q) = %q", s, django)
+	}
+	spring, err := ToSpring(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(spring, "{argon2}$argon2id$v=19$") {
+		t.Errorf("ToSpring(%q) = %q", s, spring)
+	}
+
+	for _, stored := range []string{
+		s,
+		django,
+		spring,
+		"{argon2@SpringSecurity_v5_8}" + s,
+	} {
+		ok, err := VerifyCompat(stored, []byte("password"))
+		if err != nil {
+			t.Fatalf("VerifyCompat(%q): %s", stored, err)
+		}
+		if !ok {
+			t.Errorf("VerifyCompat(%q, password) = false  want true", stored)
+		}
+
+		ok, err = VerifyCompat(stored, []byte("wrong"))
+		if err != nil {
+			t.Fatalf("VerifyCompat(%q): %s", stored, err)
+		}
+		if ok {
+			t.Errorf("VerifyCompat(%q, wrong) = true  want false", stored)
+		}
+	}
+
+	for _, stored := range []string{
+		"bcrypt$$2b$12$somethingelse",
+		"{bcrypt}$2b$12$somethingelse",
+		"{argon2x}" + s,
+	} {
+		if _, err := VerifyCompat(stored, []byte("password")); !errors.Is(err, ErrDecodingFail) {
+			t.Errorf("VerifyCompat(%q): got %v  want %v", stored, err, ErrDecodingFail)
+		}
+	}
+}
+
+func TestCompat_NoVersion(t *testing.T) {
+	// Early argon2-cffi and passlib releases omitted the version field
+	ctx := NewContext(ModeArgon2i)
+	ctx.Version = Version10
+	s, err := HashEncoded(ctx, []byte("password"), []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = strings.Replace(s, "$v=16", "", 1)
+
+	ok, err := VerifyCompat("argon2"+s, []byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Errorf("VerifyCompat(%q, password) = false  want true", "argon2"+s)
+	}
+}
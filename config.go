@@ -0,0 +1,457 @@
+package argon2
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// config is the serialized form of a Context, as written by MarshalJSON and
+// MarshalYAML.
+type config struct {
+	Mode           string `json:"mode" yaml:"mode"`
+	Version        string `json:"version" yaml:"version"`
+	Memory         string `json:"memory" yaml:"memory"`
+	Iterations     int    `json:"iterations" yaml:"iterations"`
+	Parallelism    int    `json:"parallelism" yaml:"parallelism"`
+	Threads        int    `json:"threads,omitempty" yaml:"threads,omitempty"`
+	SaltLen        int    `json:"saltLen,omitempty" yaml:"saltLen,omitempty"`
+	HashLen        int    `json:"hashLen" yaml:"hashLen"`
+	Secret         string `json:"secret,omitempty" yaml:"secret,omitempty"`
+	KeyID          string `json:"keyId,omitempty" yaml:"keyId,omitempty"`
+	AssociatedData string `json:"associatedData,omitempty" yaml:"associatedData,omitempty"`
+	Flags          int    `json:"flags,omitempty" yaml:"flags,omitempty"`
+	Normalization  string `json:"normalization,omitempty" yaml:"normalization,omitempty"`
+	MinPasswordLen int    `json:"minPasswordLen,omitempty" yaml:"minPasswordLen,omitempty"`
+	MaxPasswordLen int    `json:"maxPasswordLen,omitempty" yaml:"maxPasswordLen,omitempty"`
+
+	Prehash          string `json:"prehash,omitempty" yaml:"prehash,omitempty"`
+	PrehashThreshold int    `json:"prehashThreshold,omitempty" yaml:"prehashThreshold,omitempty"`
+}
+
+// newConfig returns the serialized form of ctx. The secret is only included
+// if secret is true.
+func newConfig(ctx *Context, secret bool) (*config, error) {
+	if !ctx.Mode.IsValid() {
+		return nil, ErrIncorrectType
+	}
+	version := ctx.Version
+	if version == 0 {
+		version = VersionDefault
+	}
+	if !version.IsValid() {
+		return nil, fmt.Errorf("argon2: unknown version %d", version)
+	}
+
+	c := &config{
+		Mode:           ctx.Mode.String(),
+		Version:        version.String(),
+		Memory:         formatMemory(ctx.Memory),
+		Iterations:     ctx.Iterations,
+		Parallelism:    ctx.Parallelism,
+		Threads:        ctx.Threads,
+		SaltLen:        ctx.SaltLen,
+		HashLen:        ctx.HashLen,
+		Flags:          ctx.Flags,
+		MinPasswordLen: ctx.MinPasswordLen,
+		MaxPasswordLen: ctx.MaxPasswordLen,
+	}
+	if ctx.Normalization != NormalizationNone {
+		c.Normalization = ctx.Normalization.String()
+	}
+	if ctx.Prehash != PrehashNone {
+		c.Prehash = ctx.Prehash.String()
+		c.PrehashThreshold = ctx.prehashThreshold()
+	}
+	if secret && len(ctx.Secret) > 0 {
+		c.Secret = base64.StdEncoding.EncodeToString(ctx.Secret)
+	}
+	if len(ctx.KeyID) > 0 {
+		c.KeyID = base64.StdEncoding.EncodeToString(ctx.KeyID)
+	}
+	if len(ctx.AssociatedData) > 0 {
+		c.AssociatedData = base64.StdEncoding.EncodeToString(ctx.AssociatedData)
+	}
+	return c, nil
+}
+
+// text formats c as comma-separated key=value pairs, omitting empty optional
+// fields.
+func (c *config) text() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "mode=%s,version=%s,memory=%s,iterations=%d,parallelism=%d,hashLen=%d",
+		c.Mode, c.Version, c.Memory, c.Iterations, c.Parallelism, c.HashLen)
+	if c.Threads != 0 {
+		fmt.Fprintf(&b, ",threads=%d", c.Threads)
+	}
+	if c.SaltLen != 0 {
+		fmt.Fprintf(&b, ",saltLen=%d", c.SaltLen)
+	}
+	if c.Secret != "" {
+		fmt.Fprintf(&b, ",secret=%s", c.Secret)
+	}
+	if c.KeyID != "" {
+		fmt.Fprintf(&b, ",keyId=%s", c.KeyID)
+	}
+	if c.AssociatedData != "" {
+		fmt.Fprintf(&b, ",associatedData=%s", c.AssociatedData)
+	}
+	if c.Flags != 0 {
+		fmt.Fprintf(&b, ",flags=%d", c.Flags)
+	}
+	if c.Normalization != "" {
+		fmt.Fprintf(&b, ",normalization=%s", c.Normalization)
+	}
+	if c.MinPasswordLen != 0 {
+		fmt.Fprintf(&b, ",minPasswordLen=%d", c.MinPasswordLen)
+	}
+	if c.MaxPasswordLen != 0 {
+		fmt.Fprintf(&b, ",maxPasswordLen=%d", c.MaxPasswordLen)
+	}
+	if c.Prehash != "" {
+		fmt.Fprintf(&b, ",prehash=%s,prehashThreshold=%d", c.Prehash, c.PrehashThreshold)
+	}
+	return b.Bytes()
+}
+
+// MarshalJSON encodes the context as a JSON object with human-friendly
+// values, for example:
+//
+//	{"mode":"argon2id","version":"1.3","memory":"64MiB","iterations":3,"parallelism":4,"hashLen":32}
+//
+// The secret is never included; use SecretContext to serialize it.
+func (ctx Context) MarshalJSON() ([]byte, error) {
+	c, err := newConfig(&ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(c)
+}
+
+// MarshalText encodes the context as comma-separated key=value pairs, using
+// the same keys and values as MarshalJSON:
+//
+//	mode=argon2id,version=1.3,memory=64MiB,iterations=3,parallelism=4,hashLen=32
+//
+// The secret is never included; use SecretContext to serialize it.
+func (ctx Context) MarshalText() ([]byte, error) {
+	c, err := newConfig(&ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	return c.text(), nil
+}
+
+// MarshalYAML implements the Marshaler interface of gopkg.in/yaml.v2 and
+// gopkg.in/yaml.v3, producing a mapping with the same keys and values as
+// MarshalJSON.
+func (ctx Context) MarshalYAML() (interface{}, error) {
+	return newConfig(&ctx, false)
+}
+
+// UnmarshalJSON decodes a context from a JSON object, or from a string in the
+// form produced by MarshalText. Fields that are not present keep their
+// current values, so defaults can be set with NewContext beforehand. Besides
+// the forms produced by MarshalJSON, memory can be given as a number in KiB,
+// and version as a number (16 or 19) or a hexadecimal string ("0x13").
+func (ctx *Context) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		return ctx.UnmarshalText([]byte(s))
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var m map[string]interface{}
+	if err := d.Decode(&m); err != nil {
+		return err
+	}
+	return ctx.set(m)
+}
+
+// UnmarshalText decodes a context from the form produced by MarshalText. Keys
+// may appear in any order; keys that are not present keep their current
+// values.
+func (ctx *Context) UnmarshalText(b []byte) error {
+	m := make(map[string]interface{})
+	for _, kv := range strings.Split(string(b), ",") {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+		p := strings.SplitN(kv, "=", 2)
+		if len(p) != 2 {
+			return fmt.Errorf("argon2: invalid context field %q", kv)
+		}
+		m[strings.TrimSpace(p[0])] = strings.TrimSpace(p[1])
+	}
+	return ctx.set(m)
+}
+
+// UnmarshalYAML implements the Unmarshaler interface of gopkg.in/yaml.v2 and
+// the equivalent obsolete interface of gopkg.in/yaml.v3. It accepts the same
+// forms as UnmarshalJSON.
+func (ctx *Context) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		return ctx.UnmarshalText([]byte(s))
+	}
+
+	var m map[string]interface{}
+	if err := unmarshal(&m); err != nil {
+		return err
+	}
+	return ctx.set(m)
+}
+
+// UnmarshalTOML implements the Unmarshaler interface of
+// github.com/BurntSushi/toml. It accepts a table, with the same keys and
+// values as UnmarshalJSON, or a string in the form produced by MarshalText.
+// Keys may also be written in snake_case, such as hash_len.
+func (ctx *Context) UnmarshalTOML(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		return ctx.UnmarshalText([]byte(v))
+	case map[string]interface{}:
+		return ctx.set(v)
+	default:
+		return fmt.Errorf("argon2: cannot decode context from %T", v)
+	}
+}
+
+// set updates the fields of ctx named in m. Keys are matched ignoring case,
+// underscores and hyphens. The context is left unchanged if any field is
+// invalid.
+func (ctx *Context) set(m map[string]interface{}) error {
+	c := *ctx
+	for key, v := range m {
+		var err error
+		switch strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key)) {
+		case "mode":
+			c.Mode, err = parseModeValue(v)
+		case "version":
+			c.Version, err = parseVersionValue(v)
+		case "memory":
+			c.Memory, err = parseMemoryValue(v)
+		case "iterations":
+			c.Iterations, err = intValue(v)
+		case "parallelism":
+			c.Parallelism, err = intValue(v)
+		case "threads":
+			c.Threads, err = intValue(v)
+		case "saltlen":
+			c.SaltLen, err = intValue(v)
+		case "hashlen":
+			c.HashLen, err = intValue(v)
+		case "flags":
+			c.Flags, err = intValue(v)
+		case "secret":
+			c.Secret, err = bytesValue(v)
+		case "keyid":
+			c.KeyID, err = bytesValue(v)
+			if err == nil && len(c.KeyID) > MaxKeyIDLen {
+				err = fmt.Errorf("longer than %d bytes", MaxKeyIDLen)
+			}
+		case "associateddata":
+			c.AssociatedData, err = bytesValue(v)
+		case "normalization":
+			c.Normalization, err = parseNormalizationValue(v)
+		case "minpasswordlen":
+			c.MinPasswordLen, err = intValue(v)
+		case "maxpasswordlen":
+			c.MaxPasswordLen, err = intValue(v)
+		case "prehash":
+			c.Prehash, err = parsePrehashValue(v)
+		case "prehashthreshold":
+			c.PrehashThreshold, err = intValue(v)
+		default:
+			return fmt.Errorf("argon2: unknown context field %q", key)
+		}
+		if err != nil {
+			return fmt.Errorf("argon2: invalid context field %q: %s", key, strings.TrimPrefix(err.Error(), "argon2: "))
+		}
+	}
+	*ctx = c
+	return nil
+}
+
+// SecretContext wraps a Context so that its secret is included when it is
+// marshaled. Use it only when writing to a store that is as protected as the
+// secret itself; a Context on its own never serializes its secret, so it can
+// safely be logged.
+type SecretContext struct {
+	*Context
+}
+
+// MarshalJSON is like Context.MarshalJSON, but includes the secret.
+func (s SecretContext) MarshalJSON() ([]byte, error) {
+	c, err := newConfig(s.Context, true)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(c)
+}
+
+// MarshalText is like Context.MarshalText, but includes the secret.
+func (s SecretContext) MarshalText() ([]byte, error) {
+	c, err := newConfig(s.Context, true)
+	if err != nil {
+		return nil, err
+	}
+	return c.text(), nil
+}
+
+// MarshalYAML is like Context.MarshalYAML, but includes the secret.
+func (s SecretContext) MarshalYAML() (interface{}, error) {
+	return newConfig(s.Context, true)
+}
+
+// formatMemory formats a memory size in KiB using the largest binary unit
+// that represents it exactly.
+func formatMemory(kib int) string {
+	switch {
+	case kib != 0 && kib%(1<<20) == 0:
+		return strconv.Itoa(kib>>20) + "GiB"
+	case kib != 0 && kib%(1<<10) == 0:
+		return strconv.Itoa(kib>>10) + "MiB"
+	default:
+		return strconv.Itoa(kib) + "KiB"
+	}
+}
+
+func parseModeValue(v interface{}) (Mode, error) {
+	s, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("expected string, got %T", v)
+	}
+	return ParseMode(s)
+}
+
+func parseVersionValue(v interface{}) (Version, error) {
+	if s, ok := v.(string); ok {
+		return ParseVersion(s)
+	}
+
+	n, err := intValue(v)
+	if err != nil {
+		return 0, err
+	}
+	if !Version(n).IsValid() {
+		return 0, fmt.Errorf("unknown version %d", n)
+	}
+	return Version(n), nil
+}
+
+func parseNormalizationValue(v interface{}) (Normalization, error) {
+	s, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("expected string, got %T", v)
+	}
+	if s == "none" {
+		return NormalizationNone, nil
+	}
+	if n, ok := parseNormalization(strings.ToLower(s)); ok {
+		return n, nil
+	}
+	return 0, fmt.Errorf("unknown normalization %q", s)
+}
+
+func parsePrehashValue(v interface{}) (Prehash, error) {
+	s, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("expected string, got %T", v)
+	}
+	if s == "none" {
+		return PrehashNone, nil
+	}
+	if p, ok := parsePrehash(strings.ToLower(s)); ok {
+		return p, nil
+	}
+	return 0, fmt.Errorf("unknown pre-hash %q", s)
+}
+
+// memoryUnits maps unit suffixes to their size in KiB.
+var memoryUnits = []struct {
+	suffix string
+	kib    int
+}{
+	{"KiB", 1},
+	{"MiB", 1 << 10},
+	{"GiB", 1 << 20},
+}
+
+func parseMemoryValue(v interface{}) (int, error) {
+	s, ok := v.(string)
+	if !ok {
+		return intValue(v)
+	}
+
+	unit := 1
+	for _, u := range memoryUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			s, unit = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.kib
+			break
+		}
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || n*uint64(unit) > math.MaxUint32 {
+		return 0, fmt.Errorf("invalid memory size %q", v)
+	}
+	return int(n) * unit, nil
+}
+
+// intValue converts a decoded number, or a string holding one, to an int
+// that fits in 32 bits.
+func intValue(v interface{}) (int, error) {
+	var n int64
+	switch v := v.(type) {
+	case int:
+		n = int64(v)
+	case int64:
+		n = v
+	case uint64:
+		if v > math.MaxUint32 {
+			return 0, fmt.Errorf("%d out of range", v)
+		}
+		n = int64(v)
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("%v is not an integer", v)
+		}
+		n = int64(v)
+	case json.Number:
+		i, err := v.Int64()
+		if err != nil {
+			return 0, err
+		}
+		n = i
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		n = i
+	default:
+		return 0, fmt.Errorf("expected number, got %T", v)
+	}
+	if n < 0 || n > math.MaxUint32 {
+		return 0, fmt.Errorf("%d out of range", n)
+	}
+	return int(n), nil
+}
+
+// bytesValue decodes a standard base64 string.
+func bytesValue(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return base64.StdEncoding.DecodeString(v)
+	default:
+		return nil, fmt.Errorf("expected base64 string, got %T", v)
+	}
+}
@@ -0,0 +1,135 @@
+package argon2
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestContextJSON(t *testing.T) {
+	ctx := &Context{
+		Iterations:     3,
+		Memory:         64 << 10,
+		Parallelism:    4,
+		HashLen:        32,
+		Mode:           ModeArgon2id,
+		Version:        Version13,
+		Secret:         []byte("pepper"),
+		KeyID:          []byte("k1"),
+		AssociatedData: []byte("ad"),
+	}
+
+	b, err := json.Marshal(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"mode":"argon2id","version":"1.3","memory":"64MiB","iterations":3,"parallelism":4,"hashLen":32,"keyId":"azE=","associatedData":"YWQ="}`
+	if string(b) != expected {
+		t.Errorf("got %s\nwant %s", b, expected)
+	}
+
+	b, err = json.Marshal(SecretContext{ctx})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"secret":"cGVwcGVy"`) {
+		t.Errorf("SecretContext: got %s", b)
+	}
+
+	ctx2 := NewContext()
+	if err := json.Unmarshal(b, ctx2); err != nil {
+		t.Fatal(err)
+	}
+	if ctx2.Mode != ctx.Mode || ctx2.Memory != ctx.Memory || ctx2.Parallelism != ctx.Parallelism ||
+		!bytes.Equal(ctx2.Secret, ctx.Secret) || !bytes.Equal(ctx2.KeyID, ctx.KeyID) ||
+		!bytes.Equal(ctx2.AssociatedData, ctx.AssociatedData) {
+		t.Errorf("round trip: got %+v  want %+v", ctx2, ctx)
+	}
+}
+
+func TestContextUnmarshal(t *testing.T) {
+	vectors := []struct {
+		in  string
+		ctx Context
+	}{
+		{`{"mode": "argon2id", "memory": "64MiB", "version": "1.3"}`,
+			Context{Mode: ModeArgon2id, Memory: 1 << 16, Version: Version13, Iterations: 3, Parallelism: 1, HashLen: 32}},
+		{`{"mode": "Argon2d", "memory": 1024, "version": 16, "iterations": 2}`,
+			Context{Mode: ModeArgon2d, Memory: 1024, Version: Version10, Iterations: 2, Parallelism: 1, HashLen: 32}},
+		{`{"memory": "1 GiB", "version": "0x13", "hash_len": 16}`,
+			Context{Mode: ModeArgon2i, Memory: 1 << 20, Version: Version13, Iterations: 3, Parallelism: 1, HashLen: 16}},
+		{`"mode=argon2id,memory=512KiB,parallelism=2,threads=1"`,
+			Context{Mode: ModeArgon2id, Memory: 512, Version: Version13, Iterations: 3, Parallelism: 2, Threads: 1, HashLen: 32}},
+	}
+
+	for _, v := range vectors {
+		ctx := NewContext()
+		if err := json.Unmarshal([]byte(v.in), ctx); err != nil {
+			t.Errorf("%s: %v", v.in, err)
+			continue
+		}
+		if ctx.Mode != v.ctx.Mode || ctx.Memory != v.ctx.Memory || ctx.Version != v.ctx.Version ||
+			ctx.Iterations != v.ctx.Iterations || ctx.Parallelism != v.ctx.Parallelism || ctx.Threads != v.ctx.Threads ||
+			ctx.HashLen != v.ctx.HashLen {
+			t.Errorf("%s: got %+v  want %+v", v.in, ctx, v.ctx)
+		}
+	}
+
+	for _, in := range []string{
+		`{"mode": "argon3"}`,
+		`{"memory": "64MB"}`,
+		`{"memory": -1}`,
+		`{"version": "1.2"}`,
+		`{"iterations": 1.5}`,
+		`{"secret": "not base64"}`,
+		`{"keyId": "MTIzNDU2Nzg5"}`,
+		`{"pepper": "x"}`,
+	} {
+		ctx := NewContext()
+		if err := json.Unmarshal([]byte(in), ctx); err == nil {
+			t.Errorf("%s: expected error", in)
+		}
+		if ctx.Memory != 1<<12 || ctx.Mode != ModeArgon2i {
+			t.Errorf("%s: context modified on error: %+v", in, ctx)
+		}
+	}
+}
+
+func TestContextText(t *testing.T) {
+	ctx := NewContext(ModeArgon2id)
+	ctx.Secret = []byte("pepper")
+	ctx.KeyID = []byte("k1")
+
+	b, err := ctx.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "mode=argon2id,version=1.3,memory=4MiB,iterations=3,parallelism=1,hashLen=32,keyId=azE="
+	if string(b) != expected {
+		t.Errorf("got %s  want %s", b, expected)
+	}
+
+	b, err = SecretContext{ctx}.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx2 := new(Context)
+	if err := ctx2.UnmarshalText(b); err != nil {
+		t.Fatal(err)
+	}
+	if ctx2.Mode != ctx.Mode || ctx2.Memory != ctx.Memory || !bytes.Equal(ctx2.Secret, ctx.Secret) ||
+		!bytes.Equal(ctx2.KeyID, ctx.KeyID) {
+		t.Errorf("round trip: got %+v  want %+v", ctx2, ctx)
+	}
+
+	// TOML decoders pass tables as maps of decoded values
+	ctx2 = NewContext()
+	err = ctx2.UnmarshalTOML(map[string]interface{}{"mode": "argon2d", "memory": "8MiB", "hash_len": int64(64)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx2.Mode != ModeArgon2d || ctx2.Memory != 8<<10 || ctx2.HashLen != 64 {
+		t.Errorf("UnmarshalTOML: got %+v", ctx2)
+	}
+}
@@ -3,25 +3,40 @@ package argon2
 // #cgo CFLAGS: -I/usr/include
 // #include <argon2.h>
 // #include "wrapper.h"
+// #include "arena.h"
 import "C"
 
+import "unsafe"
+
 // Context represents a structure that holds all static configuration values,
 // used to parameterize an Argon2 hash function.
 type Context struct {
-	Iterations     int    // number of iterations (t_cost)
-	Memory         int    // memory usage in KiB (m_cost)
-	Parallelism    int    // number of parallel threads
-	HashLen        int    // desired hash output length
-	Mode           int    // ModeArgon2d, ModeArgon2i, or ModeArgon2id
-	Version        int    // Version10 or Version13 (aka VersionDefault)
-	Secret         []byte // optional (not used by default)
-	AssociatedData []byte // optional (not used by default)
-	Flags          int    // optional (default is FlagDefault)
+	Iterations     int     // number of iterations (t_cost)
+	Memory         int     // memory usage in KiB (m_cost)
+	Parallelism    int     // number of lanes (degree of parallelism)
+	Threads        int     // optional number of threads (default Parallelism)
+	HashLen        int     // desired hash output length
+	Mode           Mode    // ModeArgon2d, ModeArgon2i, or ModeArgon2id
+	Version        Version // Version10 or Version13 (aka VersionDefault)
+	Secret         []byte  // optional (not used by default)
+	KeyID          []byte  // optional identifier of Secret, at most MaxKeyIDLen bytes
+	AssociatedData []byte  // optional (not used by default)
+	Flags          int     // optional (default is FlagDefault)
+
+	Normalization  Normalization // optional password normalization (default none)
+	MinPasswordLen int           // optional minimum password length in characters
+	MaxPasswordLen int           // optional maximum password length in characters
+
+	Prehash          Prehash // optional pre-hash of long passwords (default none)
+	PrehashThreshold int     // length in bytes above which passwords are pre-hashed
+
+	SaltLen    int        // length in bytes of generated salts (default DefaultSaltLen)
+	SaltSource SaltSource // optional source of generated salts (default crypto/rand)
 }
 
 // NewContext initializes a new Argon2 context with reasonable defaults.
 // allows the mode to be set as an optional paramter
-func NewContext(mode ...int) *Context {
+func NewContext(mode ...Mode) *Context {
 	context := &Context{
 		Iterations:  3,
 		Memory:      1 << 12, // 4 MiB
@@ -36,8 +51,33 @@ func NewContext(mode ...int) *Context {
 	return context
 }
 
+// threads returns the number of threads to hash with. Unlike the number of
+// lanes, it does not affect the result.
+func (ctx *Context) threads() int {
+	if ctx.Threads != 0 {
+		return ctx.Threads
+	}
+	return ctx.Parallelism
+}
+
 // hash password and salt
 func (ctx *Context) hash(password []byte, salt []byte) ([]byte, error) {
+	return ctx.hashWith(password, salt, false)
+}
+
+// hashArena is like hash, but allocates memory from the arena of the
+// calling OS thread. The caller must be locked to its thread and call
+// releaseArena when done.
+func (ctx *Context) hashArena(password []byte, salt []byte) ([]byte, error) {
+	return ctx.hashWith(password, salt, true)
+}
+
+// releaseArena frees the arena of the calling OS thread.
+func releaseArena() {
+	C.arena_release()
+}
+
+func (ctx *Context) hashWith(password []byte, salt []byte, arena bool) ([]byte, error) {
 
 	if len(password) == 0 {
 		return nil, ErrPassword
@@ -45,6 +85,9 @@ func (ctx *Context) hash(password []byte, salt []byte) ([]byte, error) {
 	if len(salt) == 0 {
 		return nil, ErrSalt
 	}
+	if ctx.HashLen <= 0 {
+		return nil, ErrOutputTooShort
+	}
 
 	hash := make([]byte, ctx.HashLen)
 
@@ -72,6 +115,13 @@ func (ctx *Context) hash(password []byte, salt []byte) ([]byte, error) {
 		version = ctx.Version
 	}
 
+	// optional memory callbacks
+	allocate, free := C.allocate_fptr(nil), C.deallocate_fptr(nil)
+	if arena {
+		allocate = C.allocate_fptr(unsafe.Pointer(C.arena_allocate))
+		free = C.deallocate_fptr(unsafe.Pointer(C.arena_free))
+	}
+
 	// wrapper to overcome go pointer passing limitations
 	result := C.argon2_wrapper(
 		(*C.uint8_t)(&hash[0]), C.uint32_t(ctx.HashLen),
@@ -82,9 +132,9 @@ func (ctx *Context) hash(password []byte, salt []byte) ([]byte, error) {
 		C.uint32_t(ctx.Iterations),
 		C.uint32_t(ctx.Memory),
 		C.uint32_t(ctx.Parallelism),
-		C.uint32_t(ctx.Parallelism),
+		C.uint32_t(ctx.threads()),
 		C.uint32_t(version),
-		nil, nil,
+		allocate, free,
 		C.uint32_t(flags),
 		C.argon2_type(ctx.Mode))
 
@@ -0,0 +1,289 @@
+package argon2
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+// b64 is the unpadded standard base64 alphabet used by the PHC string format.
+var b64 = base64.RawStdEncoding.Strict()
+
+// keyIDParam is the encoded string parameter holding Context.KeyID, as
+// specified for Argon2 by the PHC string format. It identifies the secret a
+// hash was computed with, without revealing it.
+const keyIDParam = "keyid"
+
+// MaxKeyIDLen is the maximum length of Context.KeyID, as set by the PHC
+// string format.
+const MaxKeyIDLen = 8
+
+// param is a single key=value pair from the parameter section of an encoded
+// string.
+type param struct {
+	key, value string
+}
+
+// encoded holds the parts of an Argon2 encoded string. Parameters beyond m, t
+// and p are kept in extra, in the order they appeared.
+type encoded struct {
+	ctx   *Context
+	salt  []byte
+	hash  []byte
+	extra []param
+}
+
+// Decode parses an Argon2 encoded string, as produced by HashEncoded. It
+// returns a context holding the parameters of the hash (with HashLen and
+// SaltLen set to the lengths of the decoded hash and salt), the salt and the
+// raw hash.
+func Decode(s string) (ctx *Context, salt, hash []byte, err error) {
+	e, err := decode(s)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(e.extra) > 0 {
+		return nil, nil, nil, decodeError(e.extra[0].key)
+	}
+
+	return e.ctx, e.salt, e.hash, nil
+}
+
+// Encode produces a crypt-like encoded string from a context, a salt and a
+// raw hash. It is the inverse of Decode. The KeyID of ctx, if any, is recorded
+// so that the secret used by Hash can be looked up for verification.
+func Encode(ctx *Context, salt, hash []byte) (string, error) {
+	if ctx == nil {
+		return "", ErrContext
+	}
+	if !ctx.Mode.IsValid() {
+		return "", ErrIncorrectType
+	}
+	if len(salt) == 0 {
+		return "", ErrSalt
+	}
+	if len(hash) == 0 {
+		return "", ErrHash
+	}
+	if len(ctx.KeyID) > MaxKeyIDLen {
+		return "", ErrIncorrectParameter
+	}
+
+	e := &encoded{ctx: ctx, salt: salt, hash: hash}
+	return e.String(), nil
+}
+
+// NeedsRehash reports whether the encoded hash s was computed with parameters
+// other than those in ctx, and should be replaced with a fresh HashEncoded
+// result once the password is known. A salt shorter than ctx.SaltLen
+// (DefaultSaltLen if zero), or a key identifier other than ctx.KeyID, also
+// call for a rehash.
+// Strings that cannot be decoded always need a rehash.
+func NeedsRehash(ctx *Context, s string) bool {
+	e, err := decode(s)
+	if err != nil || len(e.extra) > 0 {
+		return true
+	}
+
+	version := VersionDefault
+	if ctx.Version != 0 {
+		version = ctx.Version
+	}
+
+	return e.ctx.Mode != ctx.Mode ||
+		e.ctx.Version != version ||
+		e.ctx.Memory != ctx.Memory ||
+		e.ctx.Iterations != ctx.Iterations ||
+		e.ctx.Parallelism != ctx.Parallelism ||
+		e.ctx.HashLen != ctx.HashLen ||
+		e.ctx.Normalization != ctx.Normalization ||
+		e.ctx.Prehash != ctx.Prehash ||
+		!bytes.Equal(e.ctx.KeyID, ctx.KeyID) ||
+		(ctx.Prehash != PrehashNone && e.ctx.PrehashThreshold != ctx.prehashThreshold()) ||
+		e.ctx.SaltLen < ctx.saltLen()
+}
+
+// decode splits an encoded string of the form
+//
+//	$argon2<T>[$v=<num>]$m=<num>,t=<num>,p=<num>[,<key>=<value>...]$<salt>$<hash>
+//
+// into its parts. A missing version field denotes Version10, matching
+// libargon2. The keyid, norm, ph and pt parameters are decoded into the
+// context, as are the lengths of the salt and hash.
+func decode(s string) (*encoded, error) {
+	fields := strings.Split(s, "$")
+	if len(fields) < 5 || fields[0] != "" {
+		return nil, decodeError("")
+	}
+	fields = fields[1:]
+
+	mode, ok := Mode(-1), false
+	for m, name := range modeNames {
+		if fields[0] == name {
+			mode, ok = m, true
+		}
+	}
+	if !ok {
+		return nil, decodeError("mode")
+	}
+
+	ctx := &Context{Mode: mode, Version: Version10}
+	fields = fields[1:]
+
+	if strings.HasPrefix(fields[0], "v=") {
+		v, err := parseUint(fields[0][len("v="):])
+		if err != nil || !Version(v).IsValid() {
+			return nil, decodeError("v")
+		}
+		ctx.Version = Version(v)
+		fields = fields[1:]
+	}
+	if len(fields) != 3 {
+		return nil, decodeError("")
+	}
+
+	e := &encoded{ctx: ctx}
+	for i, kv := range strings.Split(fields[0], ",") {
+		p := strings.SplitN(kv, "=", 2)
+		if len(p) != 2 || p[0] == "" || p[1] == "" {
+			return nil, decodeError(p[0])
+		}
+		if i < 3 {
+			// m, t and p are mandatory and come first, in this order
+			n, err := parseUint(p[1])
+			if err != nil || p[0] != "mtp"[i:i+1] {
+				return nil, decodeError("mtp"[i : i+1])
+			}
+			switch i {
+			case 0:
+				ctx.Memory = n
+			case 1:
+				ctx.Iterations = n
+			case 2:
+				ctx.Parallelism = n
+			}
+			continue
+		}
+		if p[0] == keyIDParam {
+			id, err := b64.DecodeString(p[1])
+			if err != nil || len(id) > MaxKeyIDLen {
+				return nil, decodeError(keyIDParam)
+			}
+			ctx.KeyID = id
+			continue
+		}
+		if p[0] == normParam {
+			n, ok := parseNormalization(p[1])
+			if !ok {
+				return nil, decodeError(normParam)
+			}
+			ctx.Normalization = n
+			continue
+		}
+		if p[0] == prehashParam {
+			ph, ok := parsePrehash(p[1])
+			if !ok {
+				return nil, decodeError(prehashParam)
+			}
+			ctx.Prehash = ph
+			continue
+		}
+		if p[0] == thresholdParam {
+			n, err := parseUint(p[1])
+			if err != nil || n == 0 {
+				return nil, decodeError(thresholdParam)
+			}
+			ctx.PrehashThreshold = n
+			continue
+		}
+		e.extra = append(e.extra, param{p[0], p[1]})
+	}
+	if ctx.Parallelism == 0 {
+		return nil, decodeError("p")
+	}
+	if (ctx.Prehash == PrehashNone) != (ctx.PrehashThreshold == 0) {
+		return nil, decodeError(prehashParam)
+	}
+
+	var err error
+	if e.salt, err = b64.DecodeString(fields[1]); err != nil || len(e.salt) == 0 {
+		return nil, decodeError("salt")
+	}
+	if e.hash, err = b64.DecodeString(fields[2]); err != nil || len(e.hash) == 0 {
+		return nil, decodeError("hash")
+	}
+	ctx.HashLen = len(e.hash)
+	ctx.SaltLen = len(e.salt)
+
+	return e, nil
+}
+
+// verify hashes password with the parameters and salt of e, and compares the
+// result against the decoded hash in constant time.
+func (e *encoded) verify(password []byte) (bool, error) {
+	hash, err := e.ctx.hash(password, e.salt)
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(e.hash, hash) == 1, nil
+}
+
+// String formats e in the PHC string format understood by decode.
+func (e *encoded) String() string {
+	version := VersionDefault
+	if e.ctx.Version != 0 {
+		version = e.ctx.Version
+	}
+
+	var b strings.Builder
+	b.WriteString("$")
+	b.WriteString(e.ctx.Mode.String())
+	b.WriteString("$v=")
+	b.WriteString(strconv.Itoa(int(version)))
+	b.WriteString("$m=")
+	b.WriteString(strconv.Itoa(e.ctx.Memory))
+	b.WriteString(",t=")
+	b.WriteString(strconv.Itoa(e.ctx.Iterations))
+	b.WriteString(",p=")
+	b.WriteString(strconv.Itoa(e.ctx.Parallelism))
+	if len(e.ctx.KeyID) > 0 {
+		b.WriteString("," + keyIDParam + "=")
+		b.WriteString(b64.EncodeToString(e.ctx.KeyID))
+	}
+	if e.ctx.Normalization != NormalizationNone {
+		b.WriteString("," + normParam + "=")
+		b.WriteString(e.ctx.Normalization.String())
+	}
+	if e.ctx.Prehash != PrehashNone {
+		b.WriteString("," + prehashParam + "=")
+		b.WriteString(e.ctx.Prehash.String())
+		b.WriteString("," + thresholdParam + "=")
+		b.WriteString(strconv.Itoa(e.ctx.prehashThreshold()))
+	}
+	for _, p := range e.extra {
+		b.WriteString(",")
+		b.WriteString(p.key)
+		b.WriteString("=")
+		b.WriteString(p.value)
+	}
+	b.WriteString("$")
+	b.WriteString(b64.EncodeToString(e.salt))
+	b.WriteString("$")
+	b.WriteString(b64.EncodeToString(e.hash))
+	return b.String()
+}
+
+// parseUint parses a canonical decimal number that fits in 32 bits.
+func parseUint(s string) (int, error) {
+	if len(s) > 1 && s[0] == '0' {
+		return 0, ErrDecodingFail
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, ErrDecodingFail
+	}
+	return int(n), nil
+}
@@ -0,0 +1,136 @@
+package argon2
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	s := "$argon2i$v=16$m=65536,t=2,p=4$c29tZXNhbHQAAAAAAAAAAA$QWLzI4TY9HkL2ZTLc8g6SinwdhZewYrzz9zxCo0bkGY"
+
+	ctx, salt, hash, err := Decode(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Mode != ModeArgon2i || ctx.Version != Version10 ||
+		ctx.Memory != 1<<16 || ctx.Iterations != 2 || ctx.Parallelism != 4 || ctx.HashLen != 32 {
+		t.Errorf("Decode: got %+v", ctx)
+	}
+	if !bytes.Equal(salt, append([]byte("somesalt"), make([]byte, 8)...)) {
+		t.Errorf("Decode: got salt %q", salt)
+	}
+
+	s2, err := Encode(ctx, salt, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s2 != s {
+		t.Errorf("Encode: got %q  want %q", s2, s)
+	}
+}
+
+func TestDecode_Error(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"$argon2x$v=19$m=4096,t=3,p=1$c29tZXNhbHQ$THaZx86KeqT+xuygENqvxaYIk3zu4wH0UmqzBL/wrdQ",
+		"$argon2d$v=20$m=4096,t=3,p=1$c29tZXNhbHQ$THaZx86KeqT+xuygENqvxaYIk3zu4wH0UmqzBL/wrdQ",
+		"$argon2d$v=19$t=3,m=4096,p=1$c29tZXNhbHQ$THaZx86KeqT+xuygENqvxaYIk3zu4wH0UmqzBL/wrdQ",
+		"$argon2d$v=19$m=04096,t=3,p=1$c29tZXNhbHQ$THaZx86KeqT+xuygENqvxaYIk3zu4wH0UmqzBL/wrdQ",
+		"$argon2d$v=19$m=4096,t=3,p=1$c29tZXNhbHQ=$THaZx86KeqT+xuygENqvxaYIk3zu4wH0UmqzBL/wrdQ",
+		"$argon2d$v=19$m=4096,t=3,p=1$c29tZXNhbHQ$",
+		"$argon2d$v=19$m=4096,t=3,p=1,x=1$c29tZXNhbHQ$THaZx86KeqT+xuygENqvxaYIk3zu4wH0UmqzBL/wrdQ",
+	} {
+		if _, _, _, err := Decode(s); !errors.Is(err, ErrDecodingFail) {
+			t.Errorf("Decode(%q): got %v  want %v", s, err, ErrDecodingFail)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	ctx := NewContext(ModeArgon2d)
+	s := "$argon2d$v=19$m=4096,t=3,p=1$c29tZXNhbHQ$THaZx86KeqT+xuygENqvxaYIk3zu4wH0UmqzBL/wrdQ"
+
+	// the 8-byte salt is shorter than DefaultSaltLen
+	if !NeedsRehash(ctx, s) {
+		t.Errorf("NeedsRehash(%+v, %q) = false  want true", ctx, s)
+	}
+	ctx.SaltLen = 8
+	if NeedsRehash(ctx, s) {
+		t.Errorf("NeedsRehash(%+v, %q) = true  want false", ctx, s)
+	}
+
+	ctx.Memory = 1 << 16
+	if !NeedsRehash(ctx, s) {
+		t.Errorf("NeedsRehash(%+v, %q) = false  want true", ctx, s)
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	ctx := NewContext(ModeArgon2id)
+	ctx.Secret = []byte("pepper-2024")
+	ctx.KeyID = []byte("k2024")
+	ctx.SaltLen = 8
+	password, salt := []byte("password"), []byte("somesalt")
+
+	hash, err := Hash(ctx, password, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Encode(ctx, salt, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "$argon2id$v=19$m=4096,t=3,p=1,keyid=azIwMjQ$"; !strings.HasPrefix(s, want) {
+		t.Errorf("Encode = %q  want prefix %q", s, want)
+	}
+
+	dctx, _, _, err := Decode(s)
+	if err != nil || string(dctx.KeyID) != "k2024" {
+		t.Fatalf("Decode = %+v, %v", dctx, err)
+	}
+	if ok, err := VerifyEncodedWith(&Context{Secret: ctx.Secret}, s, password); err != nil || !ok {
+		t.Errorf("VerifyEncodedWith = %v, %v  want true", ok, err)
+	}
+
+	// without the secret the hash cannot be verified, which is not a mismatch
+	for _, c := range []*Context{nil, {Threads: 2}} {
+		ok, err := VerifyEncodedWith(c, s, password)
+		var oerr *OpError
+		if ok || !errors.Is(err, ErrMissingSecret) || !errors.Is(err, ErrInvalidParams) ||
+			!errors.As(err, &oerr) || oerr.Field != "keyid" {
+			t.Errorf("VerifyEncodedWith(%+v) = %v, %v  want ErrMissingSecret", c, ok, err)
+		}
+	}
+	if _, _, err := NewVerifier(NewContext(ModeArgon2id)).Verify(s, password); !errors.Is(err, ErrMissingSecret) {
+		t.Errorf("Verifier.Verify = %v  want ErrMissingSecret", err)
+	}
+
+	if NeedsRehash(ctx, s) {
+		t.Errorf("NeedsRehash with same key = true  want false")
+	}
+	rotated := *ctx
+	rotated.KeyID = []byte("k2025")
+	if !NeedsRehash(&rotated, s) {
+		t.Errorf("NeedsRehash with rotated key = false  want true")
+	}
+
+	// HashEncoded does not use the secret, so it does not record its key
+	if s, _ := HashEncoded(ctx, password, salt); strings.Contains(s, "keyid") {
+		t.Errorf("HashEncoded = %q  want no keyid", s)
+	}
+
+	ctx.KeyID = []byte("123456789")
+	if _, err := Encode(ctx, salt, hash); err != ErrIncorrectParameter {
+		t.Errorf("9-byte key id: got %v  want %v", err, ErrIncorrectParameter)
+	}
+	for _, s := range []string{
+		"$argon2id$v=19$m=4096,t=3,p=1,keyid=MTIzNDU2Nzg5$c29tZXNhbHQ$THaZx86KeqT+xuygENqvxaYIk3zu4wH0UmqzBL/wrdQ",
+		"$argon2id$v=19$m=4096,t=3,p=1,keyid=!$c29tZXNhbHQ$THaZx86KeqT+xuygENqvxaYIk3zu4wH0UmqzBL/wrdQ",
+	} {
+		if _, _, _, err := Decode(s); !errors.Is(err, ErrDecodingFail) {
+			t.Errorf("Decode(%q): got %v  want %v", s, err, ErrDecodingFail)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package argon2
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+)
+
+// Equalizer keeps an encoded hash of a random password for each parameter set
+// it is used with, so that login handlers can run a verification of the same
+// cost for unknown accounts as for known ones. Without it, skipping
+// VerifyEncoded for unknown users reveals which accounts exist through
+// response times:
+//
+//	s, found := lookup(user)
+//	if !found {
+//		eq.Verify(ctx, password) // always false
+//		return errLogin
+//	}
+//	ok, err := argon2.VerifyEncoded(s, password)
+//
+// An Equalizer is safe for concurrent use.
+type Equalizer struct {
+	mu      sync.Mutex
+	dummies map[dummyKey]string
+}
+
+// dummyKey holds the parameters of a context that determine the cost of a
+// verification.
+type dummyKey struct {
+	mode          Mode
+	version       Version
+	memory        int
+	iterations    int
+	parallelism   int
+	hashLen       int
+	normalization Normalization
+	prehash       Prehash
+	threshold     int
+	saltLen       int
+}
+
+// NewEqualizer returns an Equalizer without any precomputed hashes.
+func NewEqualizer() *Equalizer {
+	return &Equalizer{dummies: make(map[dummyKey]string)}
+}
+
+// Prepare computes the dummy hash for the parameters in ctx, if it does not
+// exist yet. Calling it at startup for every active parameter set keeps the
+// first call to Verify from taking twice as long.
+func (q *Equalizer) Prepare(ctx *Context) error {
+	_, err := q.dummy(ctx)
+	return err
+}
+
+// Verify verifies password against the dummy hash for the parameters in
+// ctx, computing the hash first if needed, so that the cost of normalizing
+// and pre-hashing the password is the same as for a known account. It always
+// reports false. It returns an error if ctx is invalid, or the error
+// VerifyEncoded would return for a hash with the parameters of ctx, such as
+// ErrPassword for an empty password.
+func (q *Equalizer) Verify(ctx *Context, password []byte) (bool, error) {
+	s, err := q.dummy(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	// dummy hashes are computed from a random password, so they never match
+	_, err = VerifyEncodedWith(&Context{Threads: ctx.Threads}, s, password)
+	return false, err
+}
+
+// dummy returns the dummy hash for the parameters in ctx.
+func (q *Equalizer) dummy(ctx *Context) (string, error) {
+	if ctx == nil {
+		return "", ErrContext
+	}
+
+	version := ctx.Version
+	if version == 0 {
+		version = VersionDefault
+	}
+	k := dummyKey{ctx.Mode, version, ctx.Memory, ctx.Iterations, ctx.Parallelism, ctx.HashLen, ctx.Normalization, ctx.Prehash, ctx.prehashThreshold(), ctx.saltLen()}
+
+	q.mu.Lock()
+	s, ok := q.dummies[k]
+	q.mu.Unlock()
+	if ok {
+		return s, nil
+	}
+
+	// Computed without holding the lock, so that unrelated parameter sets
+	// are not held up. A random ASCII password is unaffected by
+	// normalization.
+	password := make([]byte, 16)
+	if _, err := rand.Read(password); err != nil {
+		return "", err
+	}
+	c := *ctx
+	c.MinPasswordLen, c.MaxPasswordLen = 0, 0
+	s, err := GenerateEncoded(&c, []byte(hex.EncodeToString(password)))
+	if err != nil {
+		return "", err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if existing, ok := q.dummies[k]; ok {
+		return existing, nil
+	}
+	q.dummies[k] = s
+	return s, nil
+}
+
+var defaultEqualizer = NewEqualizer()
+
+// DummyVerify runs Verify on a package-wide Equalizer.
+func DummyVerify(ctx *Context, password []byte) (bool, error) {
+	return defaultEqualizer.Verify(ctx, password)
+}
@@ -0,0 +1,110 @@
+package argon2
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestEqualizer(t *testing.T) {
+	q := NewEqualizer()
+	ctx := NewContext(ModeArgon2id)
+	if err := q.Prepare(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	s, _ := q.dummy(ctx)
+	if s2, _ := q.dummy(NewContext(ModeArgon2id)); s2 != s {
+		t.Errorf("dummy hash not reused for equal parameters")
+	}
+	ctx.Iterations++
+	if s2, _ := q.dummy(ctx); s2 == s {
+		t.Errorf("dummy hash reused for different parameters")
+	}
+	if NeedsRehash(ctx, mustDummy(t, q, ctx)) {
+		t.Errorf("dummy hash does not use the parameters of ctx")
+	}
+
+	if ok, err := q.Verify(ctx, []byte("password")); ok || err != nil {
+		t.Errorf("Verify = %v, %v  want false, nil", ok, err)
+	}
+	if _, err := q.Verify(nil, []byte("password")); !errors.Is(err, ErrContext) {
+		t.Errorf("Verify(nil): got %v  want %v", err, ErrContext)
+	}
+	if _, err := q.Verify(ctx, nil); !errors.Is(err, ErrPassword) {
+		t.Errorf("Verify(nil password): got %v  want %v", err, ErrPassword)
+	}
+
+	ctx.Normalization = NormalizationOpaqueString
+	if ok, err := DummyVerify(ctx, []byte("password")); ok || err != nil {
+		t.Errorf("DummyVerify with normalization = %v, %v  want false, nil", ok, err)
+	}
+}
+
+func TestEqualizer_Error(t *testing.T) {
+	q := NewEqualizer()
+	ctx := NewContext(ModeArgon2id)
+	if err := q.Prepare(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// errors that VerifyEncoded would return before hashing are not hidden
+	for k := range q.dummies {
+		q.dummies[k] = "$argon2id$v=19$m=4294967295,t=3,p=1$c29tZXNhbHQ$c29tZWhhc2g"
+	}
+	if ok, err := q.Verify(ctx, []byte("password")); ok || !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Verify = %v, %v  want %v", ok, err, ErrInvalidParams)
+	}
+}
+
+func mustDummy(t *testing.T, q *Equalizer, ctx *Context) string {
+	s, err := q.dummy(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// TestEqualizer_Timing checks that a dummy verification takes as long as a
+// failed verification of an existing hash. Samples are interleaved and
+// compared by their medians, with a generous tolerance for noisy machines.
+func TestEqualizer_Timing(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping timing test in short mode")
+	}
+
+	ctx := NewContext(ModeArgon2id)
+	ctx.Memory = 1 << 13
+	s, err := HashEncoded(ctx, []byte("password"), []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := NewEqualizer()
+	if err := q.Prepare(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 21
+	var real, dummy []time.Duration
+	for i := 0; i < n; i++ {
+		start := time.Now()
+		VerifyEncoded(s, []byte("wrong password"))
+		real = append(real, time.Since(start))
+
+		start = time.Now()
+		q.Verify(ctx, []byte("wrong password"))
+		dummy = append(dummy, time.Since(start))
+	}
+
+	r, d := median(real), median(dummy)
+	if ratio := float64(d) / float64(r); ratio < 0.6 || ratio > 1.6 {
+		t.Errorf("median dummy verification %v, real verification %v", d, r)
+	}
+}
+
+func median(d []time.Duration) time.Duration {
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+	return d[len(d)/2]
+}
@@ -7,6 +7,7 @@ import "C"
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 // Error represents the internal error code propagated from libargon2.
@@ -17,13 +18,157 @@ func (e Error) Error() string {
 	return fmt.Sprintf("argon2: %s", C.GoString(msg))
 }
 
+// Is reports whether target is the category of e: ErrResourceExhausted for
+// allocation and thread failures, ErrMalformedEncoding for encoding and
+// decoding failures, ErrMismatch for ErrVerifyMismatch, and ErrInvalidParams
+// for all other codes.
+func (e Error) Is(target error) bool {
+	switch e {
+	case ErrMemoryAllocationError, ErrThreadFail:
+		return target == ErrResourceExhausted
+	case ErrEncodingFail, ErrDecodingFail, ErrDecodingLengthFail:
+		return target == ErrMalformedEncoding
+	case ErrVerifyMismatch:
+		return target == ErrMismatch
+	default:
+		return target == ErrInvalidParams
+	}
+}
+
+// OpError records the operation and the parameter that caused an error. Its
+// Err field holds an Error code or one of the package's error values, so
+// errors.Is works with both those and the error categories:
+//
+//	_, err := argon2.Hash(ctx, password, []byte("salt"))
+//	// err.Error() == "argon2: Hash salt: Salt is too short"
+//	// errors.Is(err, argon2.ErrSaltTooShort) == true
+//	// errors.Is(err, argon2.ErrInvalidParams) == true
+type OpError struct {
+	Op    string // "Hash", "Verify" or "Decode"
+	Field string // offending parameter, such as "m" or "salt"; may be empty
+	Err   error
+}
+
+func (e *OpError) Error() string {
+	msg := strings.TrimPrefix(e.Err.Error(), "argon2: ")
+	if e.Field == "" {
+		return "argon2: " + e.Op + ": " + msg
+	}
+	return "argon2: " + e.Op + " " + e.Field + ": " + msg
+}
+
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// errorFields maps errors to the parameter they concern.
+var errorFields = map[error]string{
+	ErrContext:               "ctx",
+	ErrPassword:              "password",
+	ErrSalt:                  "salt",
+	ErrHash:                  "hash",
+	ErrMissingSecret:         keyIDParam,
+	ErrNormalization:         "password",
+	ErrPasswordTooShort:      "password",
+	ErrPasswordTooLong:       "password",
+	ErrOutputPtrNull:         "hash",
+	ErrOutputTooShort:        "hashLen",
+	ErrOutputTooLong:         "hashLen",
+	ErrPwdTooShort:           "password",
+	ErrPwdTooLong:            "password",
+	ErrSaltTooShort:          "salt",
+	ErrSaltTooLong:           "salt",
+	ErrAdTooShort:            "associatedData",
+	ErrAdTooLong:             "associatedData",
+	ErrSecretTooShort:        "secret",
+	ErrSecretTooLong:         "secret",
+	ErrTimeTooSmall:          "t",
+	ErrTimeTooLarge:          "t",
+	ErrMemoryTooLittle:       "m",
+	ErrMemoryTooMuch:         "m",
+	ErrLanesTooFew:           "p",
+	ErrLanesTooMany:          "p",
+	ErrPwdPtrMismatch:        "password",
+	ErrSaltPtrMismatch:       "salt",
+	ErrSecretPtrMismatch:     "secret",
+	ErrAdPtrMismatch:         "associatedData",
+	ErrMemoryAllocationError: "m",
+	ErrIncorrectType:         "mode",
+	ErrThreadsTooFew:         "threads",
+	ErrThreadsTooMany:        "threads",
+}
+
+// opError wraps err in an OpError for op, unless it is nil or already an
+// OpError.
+func opError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*OpError); ok {
+		return err
+	}
+	var field string
+	switch err.(type) {
+	case Error, *categorized:
+		field = errorFields[err]
+	}
+	return &OpError{Op: op, Field: field, Err: err}
+}
+
+// decodeError returns the error for a malformed field of an encoded string.
+func decodeError(field string) error {
+	return &OpError{Op: "Decode", Field: field, Err: ErrDecodingFail}
+}
+
+// Error categories. Every Error code, every error value declared below, every
+// *PolicyError, and every other error from hashing, encoding, decoding and
+// verifying Argon2 hashes matches exactly one of them with errors.Is:
+//
+//	if errors.Is(err, argon2.ErrInvalidParams) {
+//		// reject the request or fix the configuration
+//	}
+//
+// Errors from other sources match none of them, even when wrapped in an
+// *OpError: errors from ParseMode, ParseVersion and the parsing of
+// configuration, from SecretBytes, from a SaltSource, from context
+// cancellation in a Hasher, and from the bcrypt and scrypt implementations
+// used by a Verifier.
+var (
+	ErrInvalidParams     = errors.New("argon2: invalid parameters")
+	ErrResourceExhausted = errors.New("argon2: resource exhausted")
+	ErrMalformedEncoding = errors.New("argon2: malformed encoding")
+	ErrMismatch          = errors.New("argon2: password does not match")
+)
+
 var (
-	ErrContext  = errors.New("argon2: context is nil")
-	ErrPassword = errors.New("argon2: password is nil or empty")
-	ErrSalt     = errors.New("argon2: salt is nil or empty")
-	ErrHash     = errors.New("argon2: hash is nil or empty")
+	ErrContext  error = &categorized{"argon2: context is nil", ErrInvalidParams}
+	ErrPassword error = &categorized{"argon2: password is nil or empty", ErrInvalidParams}
+	ErrSalt     error = &categorized{"argon2: salt is nil or empty", ErrInvalidParams}
+	ErrHash     error = &categorized{"argon2: hash is nil or empty", ErrInvalidParams}
+
+	ErrMissingSecret error = &categorized{"argon2: hash was computed with a secret, but none was given", ErrInvalidParams}
+
+	ErrUnknownScheme error = &categorized{"argon2: unknown hash scheme", ErrMalformedEncoding}
+
+	ErrNormalization    error = &categorized{"argon2: password cannot be normalized", ErrInvalidParams}
+	ErrPasswordTooShort error = &categorized{"argon2: password has too few characters", ErrInvalidParams}
+	ErrPasswordTooLong  error = &categorized{"argon2: password has too many characters", ErrInvalidParams}
 )
 
+// categorized is an error that belongs to one of the error categories.
+type categorized struct {
+	msg      string
+	category error
+}
+
+func (e *categorized) Error() string {
+	return e.msg
+}
+
+func (e *categorized) Is(target error) bool {
+	return target == e.category
+}
+
 var (
 	ErrOutputPtrNull         Error = C.ARGON2_OUTPUT_PTR_NULL
 	ErrOutputTooShort        Error = C.ARGON2_OUTPUT_TOO_SHORT
@@ -0,0 +1,62 @@
+package argon2
+
+import (
+	"errors"
+	"testing"
+)
+
+var categories = []error{ErrInvalidParams, ErrResourceExhausted, ErrMalformedEncoding, ErrMismatch}
+
+func TestErrorCategories(t *testing.T) {
+	vectors := []struct {
+		err      error
+		category error
+	}{
+		{ErrSaltTooShort, ErrInvalidParams},
+		{ErrIncorrectType, ErrInvalidParams},
+		{ErrMemoryTooMuch, ErrInvalidParams},
+		{ErrContext, ErrInvalidParams},
+		{ErrPassword, ErrInvalidParams},
+		{ErrPasswordTooLong, ErrInvalidParams},
+		{ErrMemoryAllocationError, ErrResourceExhausted},
+		{ErrThreadFail, ErrResourceExhausted},
+		{ErrDecodingFail, ErrMalformedEncoding},
+		{ErrDecodingLengthFail, ErrMalformedEncoding},
+		{ErrUnknownScheme, ErrMalformedEncoding},
+		{ErrVerifyMismatch, ErrMismatch},
+	}
+
+	for _, v := range vectors {
+		for _, c := range categories {
+			if is := errors.Is(v.err, c); is != (c == v.category) {
+				t.Errorf("errors.Is(%v, %v) = %v", v.err, c, is)
+			}
+		}
+	}
+}
+
+func TestOpError(t *testing.T) {
+	_, err := Hash(NewContext(), []byte("password"), []byte("salt"))
+	var oerr *OpError
+	if !errors.As(err, &oerr) || oerr.Op != "Hash" || oerr.Field != "salt" {
+		t.Fatalf("got %#v", err)
+	}
+	if !errors.Is(err, ErrSaltTooShort) || !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("%v does not match its code and category", err)
+	}
+	if s := err.Error(); s != "argon2: Hash salt: Salt is too short" {
+		t.Errorf("Error() = %q", s)
+	}
+
+	_, err = VerifyEncoded("$argon2id$v=19$m=4096,t=3,p=1$c29tZXNhbHQ$!", []byte("password"))
+	if !errors.As(err, &oerr) || oerr.Op != "Decode" || oerr.Field != "hash" || !errors.Is(err, ErrMalformedEncoding) {
+		t.Errorf("malformed hash: got %v", err)
+	}
+
+	ctx := NewContext()
+	ctx.Memory = 1
+	_, err = Verify(ctx, []byte("hash"), []byte("password"), []byte("somesalt"))
+	if !errors.As(err, &oerr) || oerr.Op != "Verify" || oerr.Field != "m" || !errors.Is(err, ErrMemoryTooLittle) {
+		t.Errorf("invalid memory: got %v", err)
+	}
+}
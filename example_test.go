@@ -55,3 +55,21 @@ func ExampleHashEncoded() {
 	// Output:
 	// $argon2i$v=16$m=65536,t=2,p=4$c29tZXNhbHQAAAAAAAAAAA$QWLzI4TY9HkL2ZTLc8g6SinwdhZewYrzz9zxCo0bkGY
 }
+
+func ExampleGenerateEncoded() {
+	ctx := argon2.NewContext(argon2.ModeArgon2id)
+	ctx.SaltLen = 16
+
+	// reproducible salts for this example; leave SaltSource nil in production
+	ctx.SaltSource = argon2.NewDeterministicSaltSource([]byte("example"))
+
+	s, err := argon2.GenerateEncoded(ctx, []byte("password"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(s)
+
+	// Output:
+	// $argon2id$v=19$m=4096,t=3,p=1$vBZ7r6fTLdUcToTifNBlZg$g3zmdRnIFeJfaC8hMMSgwlUUrubOAynaUAbD+DN2V6M
+}
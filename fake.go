@@ -0,0 +1,212 @@
+package argon2
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Fake is a Hasher for unit tests. It is fast and deterministic, but its
+// hashes are not Argon2 hashes: strings from Fake.HashEncoded decode like
+// real ones, yet only Fake.VerifyEncoded accepts them. The zero value is
+// ready to use.
+type Fake struct {
+	// Latency is added to every call, to exercise timeouts. A call whose
+	// context is done during the delay returns the context's error.
+	Latency time.Duration
+
+	// Err, if not nil, is returned by every call after the delay.
+	Err error
+
+	// Record enables recording of calls, including their passwords.
+	Record bool
+
+	mu    sync.Mutex
+	calls []FakeCall
+}
+
+// FakeCall is a call recorded by a Fake.
+type FakeCall struct {
+	Method   string // "Hash", "HashEncoded", "Verify", "VerifyEncoded" or "VerifyEncodedWith"
+	Params   *Context
+	Password []byte
+	Salt     []byte
+	Encoded  string // for VerifyEncoded and VerifyEncodedWith
+}
+
+// Calls returns the calls recorded so far, in order.
+func (f *Fake) Calls() []FakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeCall(nil), f.calls...)
+}
+
+// Reset forgets the recorded calls.
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+// Hash returns a fake hash of password, which depends on the same parameters
+// as a real one.
+func (f *Fake) Hash(ctx context.Context, params *Context, password, salt []byte) ([]byte, error) {
+	if err := f.call(ctx, FakeCall{Method: "Hash", Params: params, Password: password, Salt: salt}); err != nil {
+		return nil, err
+	}
+	return fakeHash(params, password, salt, "Hash")
+}
+
+// HashEncoded returns an encoded string holding a fake hash of password.
+func (f *Fake) HashEncoded(ctx context.Context, params *Context, password, salt []byte) (string, error) {
+	if err := f.call(ctx, FakeCall{Method: "HashEncoded", Params: params, Password: password, Salt: salt}); err != nil {
+		return "", err
+	}
+	if params == nil {
+		return "", opError("Hash", ErrContext)
+	}
+
+	// as with HashEncoded, the secret, associated data and flags are not used
+	c := *params
+	c.Secret, c.KeyID, c.AssociatedData, c.Flags = nil, nil, nil, FlagDefault
+	hash, err := fakeHash(&c, password, salt, "Hash")
+	if err != nil {
+		return "", err
+	}
+	e := &encoded{ctx: &c, salt: salt, hash: hash}
+	return e.String(), nil
+}
+
+// Verify reports whether hash is the fake hash of password.
+func (f *Fake) Verify(ctx context.Context, params *Context, hash, password, salt []byte) (bool, error) {
+	if err := f.call(ctx, FakeCall{Method: "Verify", Params: params, Password: password, Salt: salt}); err != nil {
+		return false, err
+	}
+	if len(hash) == 0 {
+		return false, opError("Verify", ErrHash)
+	}
+	hash2, err := fakeHash(params, password, salt, "Verify")
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(hash, hash2) == 1, nil
+}
+
+// VerifyEncoded reports whether s, as produced by Fake.HashEncoded, holds
+// the fake hash of password.
+func (f *Fake) VerifyEncoded(ctx context.Context, s string, password []byte) (bool, error) {
+	if err := f.call(ctx, FakeCall{Method: "VerifyEncoded", Password: password, Encoded: s}); err != nil {
+		return false, err
+	}
+	return fakeVerifyEncoded(nil, s, password)
+}
+
+// VerifyEncodedWith is like VerifyEncoded, but hashes with the secret and
+// associated data of params, as the package-level VerifyEncodedWith does.
+func (f *Fake) VerifyEncodedWith(ctx context.Context, params *Context, s string, password []byte) (bool, error) {
+	if err := f.call(ctx, FakeCall{Method: "VerifyEncodedWith", Params: params, Password: password, Encoded: s}); err != nil {
+		return false, err
+	}
+	return fakeVerifyEncoded(params, s, password)
+}
+
+// fakeVerifyEncoded implements Fake.VerifyEncodedWith.
+func fakeVerifyEncoded(params *Context, s string, password []byte) (bool, error) {
+	if len(password) == 0 {
+		return false, opError("Verify", ErrPassword)
+	}
+	e, err := decode(s)
+	if err != nil {
+		return false, err
+	}
+	if len(e.extra) > 0 {
+		return false, decodeError(e.extra[0].key)
+	}
+	if len(e.ctx.KeyID) > 0 && (params == nil || params.Secret == nil) {
+		return false, opError("Verify", ErrMissingSecret)
+	}
+	if params != nil {
+		e.ctx.Secret = params.Secret
+		e.ctx.AssociatedData = params.AssociatedData
+		e.ctx.Flags = params.Flags
+	}
+	hash, err := fakeHash(e.ctx, password, e.salt, "Verify")
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(e.hash, hash) == 1, nil
+}
+
+// call records c if recording is enabled, then waits for the latency.
+func (f *Fake) call(ctx context.Context, c FakeCall) error {
+	if f.Record {
+		if c.Params != nil {
+			p := *c.Params
+			c.Params = &p
+		}
+		c.Password = append([]byte(nil), c.Password...)
+		c.Salt = append([]byte(nil), c.Salt...)
+		f.mu.Lock()
+		f.calls = append(f.calls, c)
+		f.mu.Unlock()
+	}
+
+	if f.Latency > 0 {
+		t := time.NewTimer(f.Latency)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	} else if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.Err
+}
+
+// fakeHash derives a HashLen-byte digest from every input that affects a real
+// hash, after the same argument checks and password preparation.
+func fakeHash(params *Context, password, salt []byte, op string) ([]byte, error) {
+	if params == nil {
+		return nil, opError(op, ErrContext)
+	}
+	p, err := params.prepare(password)
+	if err != nil {
+		return nil, opError(op, err)
+	}
+	defer release(p, password)
+	if len(salt) == 0 {
+		return nil, opError(op, ErrSalt)
+	}
+	if params.HashLen <= 0 {
+		return nil, opError(op, ErrOutputTooShort)
+	}
+
+	x, err := blake2b.NewXOF(uint32(params.HashLen), nil)
+	if err != nil {
+		return nil, opError(op, ErrOutputTooLong)
+	}
+	version := params.Version
+	if version == 0 {
+		version = VersionDefault
+	}
+	var b [8]byte
+	for _, n := range []int{int(params.Mode), int(version), params.Memory, params.Iterations, params.Parallelism} {
+		binary.LittleEndian.PutUint64(b[:], uint64(n))
+		x.Write(b[:])
+	}
+	for _, s := range [][]byte{params.Secret, params.AssociatedData, salt, p} {
+		binary.LittleEndian.PutUint64(b[:], uint64(len(s)))
+		x.Write(b[:])
+		x.Write(s)
+	}
+
+	hash := make([]byte, params.HashLen)
+	x.Read(hash)
+	return hash, nil
+}
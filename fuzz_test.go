@@ -0,0 +1,152 @@
+package argon2
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Fuzzed encoded strings may ask for up to 4 TiB of memory. VerifyEncoded
+// rejects anything above DefaultMaxMemory, but that is still too costly to
+// hash while fuzzing, so the fuzz targets only hash within these costs.
+const (
+	fuzzMaxMemory     = 1 << 10
+	fuzzMaxIterations = 4
+	fuzzMaxLanes      = 8
+)
+
+var fuzzPolicy = &Policy{
+	MaxMemory:     fuzzMaxMemory,
+	MaxIterations: fuzzMaxIterations,
+	MaxLanes:      fuzzMaxLanes,
+}
+
+var fuzzSeeds = []string{
+	"$argon2i$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$wWKIMhR9lyDFvRz9YTZweHKfbftvj+qf+YFY4NeBbtA",
+	"$argon2i$m=65536,t=2,p=1$c29tZXNhbHQ$9sTbSlTio3Biev89thdrlKKiCaYsjjYVJxGAL3swxpQ",
+	"$argon2d$v=19$m=4096,t=3,p=1$c29tZXNhbHQ$THaZx86KeqT+xuygENqvxaYIk3zu4wH0UmqzBL/wrdQ",
+	"$argon2id$v=19$m=64,t=1,p=1$c29tZXNhbHQ$Pb9OQA",
+	"$argon2id$v=19$m=64,t=1,p=1,norm=nfkc$c29tZXNhbHQ$Pb9OQA",
+	"$argon2id$v=19$m=64,t=1,p=1,keyid=azE$c29tZXNhbHQ$Pb9OQA",
+	"$argon2id$v=19$m=64,t=1,p=1,ph=blake2b-512,pt=64$c29tZXNhbHQ$Pb9OQA",
+	"$argon2id$v=19$m=64,t=1,p=1,data=c29tZWRhdGE$c29tZXNhbHQ$Pb9OQA",
+	"$argon2id$v=19$m=4294967295,t=4294967295,p=16777215$c29tZXNhbHQ$Pb9OQA",
+	"$argon2id$v=19$m=4294967295,t=1,p=1$c29tZXNhbHQ$Pb9OQA",
+	"$argon2id$v=19$m=4194305,t=1,p=1$c29tZXNhbHQ$Pb9OQA",
+	"$argon2id$v=19$m=64,t=1,p=0$c29tZXNhbHQ$Pb9OQA",
+	"$argon2id$v=19$m=,t=1,p=1$$",
+	"$argon2x$v=19$m=64,t=1,p=1$c29tZXNhbHQ$Pb9OQA",
+	"$argon2id$$$$",
+	"",
+}
+
+func FuzzDecode(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		ctx, salt, hash, err := Decode(s)
+		if err != nil {
+			if !errors.Is(err, ErrMalformedEncoding) {
+				t.Fatalf("Decode(%q): error %v is not ErrMalformedEncoding", s, err)
+			}
+			return
+		}
+
+		s2, err := Encode(ctx, salt, hash)
+		if err != nil {
+			t.Fatalf("Encode(Decode(%q)): %v", s, err)
+		}
+		ctx2, salt2, hash2, err := Decode(s2)
+		if err != nil {
+			t.Fatalf("Decode(%q): %v", s2, err)
+		}
+		if !reflect.DeepEqual(ctx2, ctx) || !bytes.Equal(salt2, salt) || !bytes.Equal(hash2, hash) {
+			t.Fatalf("round trip of %q through %q: got %+v  want %+v", s, s2, ctx2, ctx)
+		}
+		if s3, _ := Encode(ctx2, salt2, hash2); s3 != s2 {
+			t.Fatalf("Encode is not stable: %q, then %q", s2, s3)
+		}
+	})
+}
+
+func FuzzVerifyEncoded(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s, []byte("password"))
+		f.Add(s, []byte(nil))
+	}
+
+	f.Fuzz(func(t *testing.T, s string, password []byte) {
+		ok, err := fuzzPolicy.VerifyEncoded(s, password)
+		if err != nil {
+			if ok {
+				t.Fatalf("VerifyEncoded(%q) = true with error %v", s, err)
+			}
+			if !errors.Is(err, ErrInvalidParams) && !errors.Is(err, ErrMalformedEncoding) &&
+				!errors.Is(err, ErrResourceExhausted) {
+				t.Fatalf("VerifyEncoded(%q): uncategorized error %v", s, err)
+			}
+		}
+		if err == nil && fuzzPolicy.Check(s) != nil {
+			t.Fatalf("VerifyEncoded(%q) hashed despite violating the policy", s)
+		}
+		if len(password) == 0 && !errors.Is(err, ErrPassword) {
+			t.Fatalf("VerifyEncoded(%q) with empty password: got %v  want %v", s, err, ErrPassword)
+		}
+
+		// without a policy, memory is still bounded
+		if ctx, _, _, err := Decode(s); err == nil && ctx.Memory > DefaultMaxMemory {
+			ok, err := VerifyEncoded(s, password)
+			if ok || !errors.Is(err, ErrInvalidParams) {
+				t.Fatalf("VerifyEncoded(%q) with m above DefaultMaxMemory: got %v, %v", s, ok, err)
+			}
+		}
+	})
+}
+
+func FuzzHashEncodedRoundTrip(f *testing.F) {
+	f.Add([]byte("password"), []byte("somesalt"), uint8(ModeArgon2id), uint16(64), uint8(1), uint8(1), uint8(32))
+	f.Add([]byte("p"), []byte("saltsalt"), uint8(ModeArgon2i), uint16(256), uint8(2), uint8(2), uint8(4))
+	f.Add([]byte("\x00\xff"), bytes.Repeat([]byte{0xaa}, 64), uint8(ModeArgon2d), uint16(16), uint8(1), uint8(2), uint8(255))
+
+	f.Fuzz(func(t *testing.T, password, salt []byte, mode uint8, m uint16, iterations, lanes, hashLen uint8) {
+		ctx := &Context{
+			Mode:        Mode(mode % 3),
+			Memory:      int(m % fuzzMaxMemory),
+			Iterations:  int(iterations % fuzzMaxIterations),
+			Parallelism: int(lanes % fuzzMaxLanes),
+			HashLen:     int(hashLen),
+			Version:     VersionDefault,
+		}
+
+		s, err := HashEncoded(ctx, password, salt)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidParams) {
+				t.Fatalf("HashEncoded(%+v): uncategorized error %v", ctx, err)
+			}
+			return
+		}
+
+		ctx2, salt2, hash, err := Decode(s)
+		if err != nil {
+			t.Fatalf("Decode(%q): %v", s, err)
+		}
+		if ctx2.Mode != ctx.Mode || ctx2.Memory != ctx.Memory || ctx2.Iterations != ctx.Iterations ||
+			ctx2.Parallelism != ctx.Parallelism || ctx2.HashLen != ctx.HashLen || !bytes.Equal(salt2, salt) {
+			t.Fatalf("Decode(%q) = %+v, %x  want %+v, %x", s, ctx2, salt2, ctx, salt)
+		}
+		if s2, err := Encode(ctx2, salt2, hash); err != nil || s2 != s {
+			t.Fatalf("Encode(Decode(%q)) = %q, %v", s, s2, err)
+		}
+
+		raw, err := Hash(ctx, password, salt)
+		if err != nil || !bytes.Equal(raw, hash) {
+			t.Fatalf("Hash = %x, %v  want %x", raw, err, hash)
+		}
+		if ok, err := VerifyEncoded(s, password); err != nil || !ok {
+			t.Fatalf("VerifyEncoded(%q) = %v, %v  want true", s, ok, err)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package argon2
+
+import (
+	"context"
+)
+
+// Hasher abstracts the package-level hashing functions, so that services can
+// swap libargon2 for another implementation, a remote service or a Fake.
+// Implementations must be safe for concurrent use.
+type Hasher interface {
+	Hash(ctx context.Context, params *Context, password, salt []byte) ([]byte, error)
+	HashEncoded(ctx context.Context, params *Context, password, salt []byte) (string, error)
+	Verify(ctx context.Context, params *Context, hash, password, salt []byte) (bool, error)
+	VerifyEncoded(ctx context.Context, s string, password []byte) (bool, error)
+	VerifyEncodedWith(ctx context.Context, params *Context, s string, password []byte) (bool, error)
+}
+
+// CgoHasher implements Hasher with libargon2, as the package-level functions
+// of the same names do. A running hash cannot be interrupted, so ctx is only
+// checked before hashing starts. It is also passed to the Observer, as the
+// context of each Event, and to StartObserver.Start.
+type CgoHasher struct {
+	// Policy, if not nil, is enforced by VerifyEncoded and VerifyEncodedWith.
+	Policy *Policy
+}
+
+// DefaultHasher is a CgoHasher without a Policy.
+var DefaultHasher Hasher = CgoHasher{}
+
+// Hash is like the package-level Hash, unless ctx is done.
+func (h CgoHasher) Hash(ctx context.Context, params *Context, password, salt []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	o := begin(ctx, "Hash")
+	hash, err := hashRaw(params, password, salt)
+	o.end(params, true, err)
+	return hash, err
+}
+
+// HashEncoded is like the package-level HashEncoded, unless ctx is done.
+func (h CgoHasher) HashEncoded(ctx context.Context, params *Context, password, salt []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	o := begin(ctx, "HashEncoded")
+	s, err := hashEncoded(params, password, salt)
+	o.end(params, true, err)
+	return s, err
+}
+
+// Verify is like the package-level Verify, unless ctx is done.
+func (h CgoHasher) Verify(ctx context.Context, params *Context, hash, password, salt []byte) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	o := begin(ctx, "Verify")
+	ok, err := verify(params, hash, password, salt)
+	o.end(params, ok, err)
+	return ok, err
+}
+
+// VerifyEncoded is like the package-level VerifyEncoded, or that of
+// h.Policy, unless ctx is done.
+func (h CgoHasher) VerifyEncoded(ctx context.Context, s string, password []byte) (bool, error) {
+	return h.VerifyEncodedWith(ctx, nil, s, password)
+}
+
+// VerifyEncodedWith is like the package-level VerifyEncodedWith, or that of
+// h.Policy, unless ctx is done.
+func (h CgoHasher) VerifyEncodedWith(ctx context.Context, params *Context, s string, password []byte) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	o := begin(ctx, "VerifyEncoded")
+	decoded, ok, err := verifyEncoded(params, h.Policy, s, password)
+	o.end(decoded, ok, err)
+	return ok, err
+}
@@ -0,0 +1,181 @@
+package argon2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	_ Hasher = CgoHasher{}
+	_ Hasher = (*Fake)(nil)
+)
+
+func testHasher(t *testing.T, h Hasher) {
+	ctx := context.Background()
+	params := NewContext(ModeArgon2id)
+	password, salt := []byte("password"), []byte("somesalt")
+
+	hash, err := h.Hash(ctx, params, password, salt)
+	if err != nil || len(hash) != params.HashLen {
+		t.Fatalf("Hash = %x, %v", hash, err)
+	}
+	if ok, err := h.Verify(ctx, params, hash, password, salt); err != nil || !ok {
+		t.Errorf("Verify = %v, %v  want true", ok, err)
+	}
+	if ok, err := h.Verify(ctx, params, hash, []byte("wrong"), salt); err != nil || ok {
+		t.Errorf("Verify(wrong) = %v, %v  want false", ok, err)
+	}
+
+	s, err := h.HashEncoded(ctx, params, password, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, hash2, err := Decode(s); err != nil || !bytes.Equal(hash, hash2) {
+		t.Errorf("Decode(%q) = %x, %v  want %x", s, hash2, err, hash)
+	}
+	if ok, err := h.VerifyEncoded(ctx, s, password); err != nil || !ok {
+		t.Errorf("VerifyEncoded = %v, %v  want true", ok, err)
+	}
+	if ok, err := h.VerifyEncoded(ctx, s, []byte("wrong")); err != nil || ok {
+		t.Errorf("VerifyEncoded(wrong) = %v, %v  want false", ok, err)
+	}
+
+	if _, err := h.Hash(ctx, params, nil, salt); !errors.Is(err, ErrPassword) {
+		t.Errorf("Hash(nil password): got %v  want %v", err, ErrPassword)
+	}
+	if _, err := h.VerifyEncoded(ctx, "$argon2id$v=19$bogus", password); !errors.Is(err, ErrMalformedEncoding) {
+		t.Errorf("VerifyEncoded(malformed): got %v  want %v", err, ErrMalformedEncoding)
+	}
+
+	// hashes computed with a secret are verified with VerifyEncodedWith
+	peppered := *params
+	peppered.Secret, peppered.KeyID = []byte("pepper"), []byte("k1")
+	hash, err = h.Hash(ctx, &peppered, password, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = Encode(&peppered, salt, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := h.VerifyEncodedWith(ctx, &Context{Secret: peppered.Secret}, s, password); err != nil || !ok {
+		t.Errorf("VerifyEncodedWith = %v, %v  want true", ok, err)
+	}
+	if ok, err := h.VerifyEncodedWith(ctx, &Context{Secret: []byte("other")}, s, password); err != nil || ok {
+		t.Errorf("VerifyEncodedWith(other secret) = %v, %v  want false", ok, err)
+	}
+	if _, err := h.VerifyEncoded(ctx, s, password); !errors.Is(err, ErrMissingSecret) {
+		t.Errorf("VerifyEncoded(peppered): got %v  want %v", err, ErrMissingSecret)
+	}
+
+	// passwords are normalized and checked as by the package-level functions
+	prepared := *params
+	prepared.Normalization = NormalizationNFKC
+	prepared.MinPasswordLen = 5
+	h1, err1 := h.Hash(ctx, &prepared, []byte("\u00e9tude"), salt)
+	h2, err2 := h.Hash(ctx, &prepared, []byte("e\u0301tude"), salt)
+	if err1 != nil || err2 != nil || !bytes.Equal(h1, h2) {
+		t.Errorf("Hash with NFKC: got %x, %v and %x, %v  want equal hashes", h1, err1, h2, err2)
+	}
+	if _, err := h.Hash(ctx, &prepared, []byte("abcd"), salt); !errors.Is(err, ErrPasswordTooShort) {
+		t.Errorf("Hash(short password): got %v  want %v", err, ErrPasswordTooShort)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := h.Hash(canceled, params, password, salt); err != context.Canceled {
+		t.Errorf("Hash(canceled): got %v  want %v", err, context.Canceled)
+	}
+	if _, err := h.VerifyEncoded(canceled, s, password); err != context.Canceled {
+		t.Errorf("VerifyEncoded(canceled): got %v  want %v", err, context.Canceled)
+	}
+	if _, err := h.VerifyEncodedWith(canceled, &peppered, s, password); err != context.Canceled {
+		t.Errorf("VerifyEncodedWith(canceled): got %v  want %v", err, context.Canceled)
+	}
+}
+
+func TestCgoHasher(t *testing.T) {
+	testHasher(t, DefaultHasher)
+
+	params := NewContext(ModeArgon2id)
+	s, err := HashEncoded(params, []byte("password"), []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := CgoHasher{Policy: &Policy{MaxMemory: 1 << 10}}
+	var perr *PolicyError
+	if _, err := h.VerifyEncoded(context.Background(), s, []byte("password")); !errors.As(err, &perr) {
+		t.Errorf("VerifyEncoded with policy: got %v  want a *PolicyError", err)
+	}
+}
+
+func TestFake(t *testing.T) {
+	testHasher(t, new(Fake))
+
+	// fake hashes depend on the parameters, but are not Argon2 hashes
+	f := new(Fake)
+	params := NewContext(ModeArgon2id)
+	password, salt := []byte("password"), []byte("somesalt")
+	h1, _ := f.Hash(context.Background(), params, password, salt)
+	params.Iterations++
+	h2, _ := f.Hash(context.Background(), params, password, salt)
+	h3, _ := Hash(params, password, salt)
+	if bytes.Equal(h1, h2) || bytes.Equal(h2, h3) {
+		t.Errorf("got %x, %x and %x", h1, h2, h3)
+	}
+}
+
+func TestFake_Record(t *testing.T) {
+	f := &Fake{Record: true}
+	ctx := context.Background()
+	params := NewContext()
+	password := []byte("password")
+
+	s, _ := f.HashEncoded(ctx, params, password, []byte("somesalt"))
+	f.VerifyEncoded(ctx, s, []byte("other"))
+	params.Memory = 1 // recorded calls keep their own copy
+	password[0] = 'P'
+
+	calls := f.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("got %d calls  want 2", len(calls))
+	}
+	if c := calls[0]; c.Method != "HashEncoded" || c.Params.Memory != 1<<12 ||
+		string(c.Password) != "password" || string(c.Salt) != "somesalt" {
+		t.Errorf("got %+v", c)
+	}
+	if c := calls[1]; c.Method != "VerifyEncoded" || c.Encoded != s || string(c.Password) != "other" {
+		t.Errorf("got %+v", c)
+	}
+
+	f.Reset()
+	if len(f.Calls()) != 0 {
+		t.Errorf("Reset did not forget calls")
+	}
+	f.Record = false
+	f.Hash(ctx, params, password, []byte("somesalt"))
+	if len(f.Calls()) != 0 {
+		t.Errorf("recorded with Record unset")
+	}
+}
+
+func TestFake_Latency(t *testing.T) {
+	errDown := errors.New("down")
+	f := &Fake{Latency: 20 * time.Millisecond, Err: errDown}
+	params := NewContext()
+
+	start := time.Now()
+	_, err := f.Hash(context.Background(), params, []byte("password"), []byte("somesalt"))
+	if d := time.Since(start); d < f.Latency || err != errDown {
+		t.Errorf("got %v after %v  want %v after at least %v", err, d, errDown, f.Latency)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := f.Hash(ctx, params, []byte("password"), []byte("somesalt")); err != context.DeadlineExceeded {
+		t.Errorf("got %v  want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,272 @@
+package argon2
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// kat is a known-answer test vector from testdata/kat. Blocks of
+// "key = value" lines hold:
+//
+//	mode, version, m, t, p    parameters
+//	password, salt, secret, ad, tag    hex-encoded byte strings
+//	taglen    tag length, for vectors without a tag
+//	encoded   optional encoded string of the vector
+//	error     expected libargon2 error, for vectors without a tag
+type kat struct {
+	name     string
+	ctx      *Context
+	password []byte
+	salt     []byte
+	tag      []byte
+	encoded  string
+	err      error
+}
+
+var katErrors = map[string]error{
+	"ARGON2_OUTPUT_TOO_SHORT": ErrOutputTooShort,
+	"ARGON2_SALT_TOO_SHORT":   ErrSaltTooShort,
+	"ARGON2_LANES_TOO_MANY":   ErrLanesTooMany,
+}
+
+func readKATs(t *testing.T) []*kat {
+	files, err := filepath.Glob(filepath.Join("testdata", "kat", "*.txt"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no test vectors: %v", err)
+	}
+
+	var kats []*kat
+	for _, file := range files {
+		kats = append(kats, readKATFile(t, file)...)
+	}
+	return kats
+}
+
+func readKATFile(t *testing.T, file string) []*kat {
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var kats []*kat
+	var v *kat
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 1<<16)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			if text == "" {
+				v = nil
+			}
+			continue
+		}
+		if v == nil {
+			v = &kat{name: fmt.Sprintf("%s:%d", filepath.Base(file), line), ctx: &Context{}}
+			kats = append(kats, v)
+		}
+		if err := v.set(text); err != nil {
+			t.Fatalf("%s:%d: %v", file, line, err)
+		}
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return kats
+}
+
+func (v *kat) set(line string) error {
+	kv := strings.SplitN(line, "=", 2)
+	if len(kv) != 2 {
+		return fmt.Errorf("malformed line %q", line)
+	}
+	key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+
+	var err error
+	switch key {
+	case "mode":
+		v.ctx.Mode, err = ParseMode(value)
+	case "version", "m", "t", "p", "taglen":
+		var n int
+		n, err = strconv.Atoi(value)
+		switch key {
+		case "version":
+			v.ctx.Version = Version(n)
+		case "m":
+			v.ctx.Memory = n
+		case "t":
+			v.ctx.Iterations = n
+		case "p":
+			v.ctx.Parallelism = n
+		case "taglen":
+			v.ctx.HashLen = n
+		}
+	case "password":
+		v.password, err = hex.DecodeString(value)
+	case "salt":
+		v.salt, err = hex.DecodeString(value)
+	case "secret":
+		v.ctx.Secret, err = hex.DecodeString(value)
+	case "ad":
+		v.ctx.AssociatedData, err = hex.DecodeString(value)
+	case "tag":
+		v.tag, err = hex.DecodeString(value)
+		v.ctx.HashLen = len(v.tag)
+	case "encoded":
+		v.encoded = value
+	case "error":
+		if v.err = katErrors[value]; v.err == nil {
+			err = fmt.Errorf("unknown error %q", value)
+		}
+	default:
+		err = fmt.Errorf("unknown key %q", key)
+	}
+	return err
+}
+
+// katBackends are the Hasher implementations held to the known answers. Add
+// any new implementation of Argon2 here; Fake does not compute Argon2 hashes.
+var katBackends = []struct {
+	name string
+	h    Hasher
+}{
+	{"CgoHasher", DefaultHasher},
+}
+
+func TestKAT(t *testing.T) {
+	kats := readKATs(t)
+	for _, b := range katBackends {
+		b := b
+		t.Run(b.name, func(t *testing.T) {
+			testKATs(t, b.h, kats)
+		})
+	}
+}
+
+// testKATs checks h against every vector in kats.
+func testKATs(t *testing.T, h Hasher, kats []*kat) {
+	ctx := context.Background()
+	for _, v := range kats {
+		v := v
+		t.Run(v.name, func(t *testing.T) {
+			if testing.Short() && v.ctx.Memory > 1<<16 {
+				t.Skip("skipping vector above 64 MiB in short mode")
+			}
+			if v.err != nil {
+				_, err := h.Hash(ctx, v.ctx, v.password, v.salt)
+				if !errors.Is(err, v.err) {
+					t.Errorf("Hash: got %v  want %v", err, v.err)
+				}
+				return
+			}
+
+			hash, err := h.Hash(ctx, v.ctx, v.password, v.salt)
+			checkKAT(t, "Hash", v, hash, err)
+
+			if ok, err := h.Verify(ctx, v.ctx, v.tag, v.password, v.salt); err != nil || !ok {
+				t.Errorf("Verify = %v, %v  want true", ok, err)
+			}
+
+			if v.encoded != "" {
+				testKATEncoded(t, h, v)
+			}
+		})
+	}
+}
+
+// TestKAT_Cgo checks the ways of hashing that libargon2 offers beyond the
+// Hasher interface.
+func TestKAT_Cgo(t *testing.T) {
+	kats := readKATs(t)
+	for _, v := range kats {
+		v := v
+		t.Run(v.name, func(t *testing.T) {
+			if testing.Short() && v.ctx.Memory > 1<<16 {
+				t.Skip("skipping vector above 64 MiB in short mode")
+			}
+			r := HashBatch(v.ctx, []Input{{v.password, v.salt}})
+			if v.err != nil {
+				if !errors.Is(r[0].Err, v.err) {
+					t.Errorf("HashBatch: got %v  want %v", r[0].Err, v.err)
+				}
+				return
+			}
+			checkKAT(t, "HashBatch", v, r[0].Hash, r[0].Err)
+
+			single := *v.ctx
+			single.Threads = 1
+			hash, err := Hash(&single, v.password, v.salt)
+			checkKAT(t, "Hash with 1 thread", v, hash, err)
+		})
+	}
+
+	// all vectors of a mode and version at once, in order
+	var inputs []Input
+	var want []*kat
+	for _, v := range kats {
+		if v.err == nil && v.ctx.Mode == ModeArgon2id && v.ctx.Version == Version13 &&
+			v.ctx.Memory == 64 && v.ctx.Iterations == 1 && v.ctx.Parallelism == 1 && v.ctx.HashLen == 32 {
+			inputs = append(inputs, Input{v.password, v.salt})
+			want = append(want, v)
+		}
+	}
+	if len(inputs) < 2 {
+		t.Fatalf("got %d batch vectors  want at least 2", len(inputs))
+	}
+	for i, r := range HashBatch(want[0].ctx, inputs) {
+		checkKAT(t, "HashBatch", want[i], r.Hash, r.Err)
+	}
+}
+
+func checkKAT(t *testing.T, name string, v *kat, hash []byte, err error) {
+	t.Helper()
+	if err != nil {
+		t.Errorf("%s (%s): %v", name, v.name, err)
+	} else if !bytes.Equal(hash, v.tag) {
+		t.Errorf("%s (%s):\n got %x\nwant %x", name, v.name, hash, v.tag)
+	}
+}
+
+func testKATEncoded(t *testing.T, h Hasher, v *kat) {
+	ctx := context.Background()
+	if ok, err := h.VerifyEncodedWith(ctx, v.ctx, v.encoded, v.password); err != nil || !ok {
+		t.Errorf("VerifyEncodedWith = %v, %v  want true", ok, err)
+	}
+	if v.ctx.Secret == nil && v.ctx.AssociatedData == nil {
+		if ok, err := h.VerifyEncoded(ctx, v.encoded, v.password); err != nil || !ok {
+			t.Errorf("VerifyEncoded = %v, %v  want true", ok, err)
+		}
+	}
+
+	params, salt, hash, err := Decode(v.encoded)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if params.Mode != v.ctx.Mode || params.Version != v.ctx.Version || params.Memory != v.ctx.Memory ||
+		params.Iterations != v.ctx.Iterations || params.Parallelism != v.ctx.Parallelism ||
+		!bytes.Equal(salt, v.salt) || !bytes.Equal(hash, v.tag) {
+		t.Errorf("Decode = %+v, %x, %x", params, salt, hash)
+	}
+
+	s, err := Encode(params, salt, hash)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	// strings from before version 0x13 may omit the version
+	if want := v.encoded; s != want && strings.Replace(s, "$v=16$", "$", 1) != want {
+		t.Errorf("Encode:\n got %s\nwant %s", s, want)
+	}
+
+	if s, err := h.HashEncoded(ctx, v.ctx, v.password, v.salt); err != nil || s != v.encoded && v.ctx.Version == Version13 {
+		t.Errorf("HashEncoded:\n got %s, %v\nwant %s", s, err, v.encoded)
+	}
+}
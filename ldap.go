@@ -0,0 +1,64 @@
+package argon2
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+)
+
+// LDAPScheme is the userPassword scheme tag used by OpenLDAP's argon2
+// password module.
+const LDAPScheme = "{ARGON2}"
+
+// HashLDAP hashes a password and produces a userPassword attribute value of
+// the form {ARGON2}$argon2id$v=19$...
+func HashLDAP(ctx *Context, password, salt []byte) ([]byte, error) {
+	s, err := HashEncoded(ctx, password, salt)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(LDAPScheme + s), nil
+}
+
+// ToLDAP converts an encoded string to a userPassword attribute value.
+func ToLDAP(s string) ([]byte, error) {
+	if _, err := decode(s); err != nil {
+		return nil, err
+	}
+	return []byte(LDAPScheme + s), nil
+}
+
+// FromLDAP converts a userPassword attribute value to an encoded string
+// accepted by VerifyEncoded. The scheme tag is matched case-insensitively.
+// Besides the textual form, it accepts the binary variants some directories
+// return: values terminated by a NUL byte and base64 encoded values, as
+// found in LDIF exports (userPassword:: e0FSR09OMn0k...).
+func FromLDAP(value []byte) (string, error) {
+	value = bytes.TrimRight(value, "\x00")
+	if len(value) > 0 && value[0] != '{' {
+		if dec, err := base64.StdEncoding.DecodeString(string(value)); err == nil {
+			value = bytes.TrimRight(dec, "\x00")
+		}
+	}
+
+	if len(value) < len(LDAPScheme) ||
+		!strings.EqualFold(string(value[:len(LDAPScheme)]), LDAPScheme) {
+		return "", ErrUnknownScheme
+	}
+
+	s := string(value[len(LDAPScheme):])
+	if _, err := decode(s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// VerifyLDAP verifies a userPassword attribute value against a plaintext
+// password.
+func VerifyLDAP(value, password []byte) (bool, error) {
+	s, err := FromLDAP(value)
+	if err != nil {
+		return false, err
+	}
+	return VerifyEncoded(s, password)
+}
@@ -0,0 +1,47 @@
+package argon2
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLDAP(t *testing.T) {
+	ctx := NewContext(ModeArgon2id)
+	value, err := HashLDAP(ctx, []byte("password"), []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(value), "{ARGON2}$argon2id$v=19$") {
+		t.Fatalf("HashLDAP: got %q", value)
+	}
+
+	s := string(value[len(LDAPScheme):])
+	for _, v := range [][]byte{
+		value,
+		[]byte("{argon2}" + s),
+		append(value, 0),
+		[]byte(base64.StdEncoding.EncodeToString(value)),
+	} {
+		ok, err := VerifyLDAP(v, []byte("password"))
+		if err != nil {
+			t.Fatalf("VerifyLDAP(%q): %s", v, err)
+		}
+		if !ok {
+			t.Errorf("VerifyLDAP(%q, password) = false  want true", v)
+		}
+
+		ok, err = VerifyLDAP(v, []byte("wrong"))
+		if err != nil {
+			t.Fatalf("VerifyLDAP(%q): %s", v, err)
+		}
+		if ok {
+			t.Errorf("VerifyLDAP(%q, wrong) = true  want false", v)
+		}
+	}
+
+	if _, err := VerifyLDAP([]byte("{SSHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g="), []byte("password")); !errors.Is(err, ErrUnknownScheme) {
+		t.Errorf("got %v  want %v", err, ErrUnknownScheme)
+	}
+}
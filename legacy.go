@@ -0,0 +1,209 @@
+package argon2
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/blowfish"
+)
+
+// Legacy schemes that can be wrapped with WrapLegacy.
+const (
+	LegacyMD5    = "md5"    // unsalted MD5, stored as a hex digest
+	LegacySHA1   = "sha1"   // unsalted SHA-1, stored as a hex digest
+	LegacyBcrypt = "bcrypt" // bcrypt, stored as a $2<minor>$<cost>$ string
+)
+
+// innerParam is the encoded string parameter naming the wrapped scheme.
+const innerParam = "inner"
+
+// WrapLegacy upgrades a legacy hash at rest, without knowing the password, by
+// using the legacy digest as the password input to Argon2. The result is an
+// encoded string whose "inner" parameter names the legacy scheme, for
+// example:
+//
+//	$argon2id$v=19$m=4096,t=3,p=1,inner=sha1$<salt>$<hash>
+//
+// Wrapped strings are verified with VerifyWrapped, which recomputes the
+// legacy digest of the password before hashing it with Argon2. They are not
+// accepted by VerifyEncoded. Scheme is one of LegacyMD5, LegacySHA1 or
+// LegacyBcrypt. As with HashEncoded, the secret, key id, associated data and
+// flags of ctx are not used, and neither is its pre-hash, since the legacy
+// digest is short.
+func WrapLegacy(ctx *Context, scheme, legacy string, salt []byte) (string, error) {
+	if ctx == nil {
+		return "", ErrContext
+	}
+
+	var inner string
+	var digest []byte
+	switch scheme {
+	case LegacyMD5, LegacySHA1:
+		size := md5.Size
+		if scheme == LegacySHA1 {
+			size = sha1.Size
+		}
+		d, err := hex.DecodeString(legacy)
+		if err != nil || len(d) != size {
+			return "", ErrDecodingFail
+		}
+		inner, digest = scheme, d
+	case LegacyBcrypt:
+		minor, cost, bsalt, err := parseBcrypt(legacy)
+		if err != nil {
+			return "", err
+		}
+		inner = fmt.Sprintf("%s-%s-%d-%s", LegacyBcrypt, minor, cost, bsalt)
+		digest = []byte(legacy)
+	default:
+		return "", ErrUnknownScheme
+	}
+
+	// the legacy digest is binary, so it is never normalized
+	c := *ctx
+	c.Secret, c.KeyID, c.AssociatedData, c.Flags = nil, nil, nil, FlagDefault
+	c.Normalization, c.Prehash = NormalizationNone, PrehashNone
+	hash, err := c.hash(digest, salt)
+	if err != nil {
+		return "", err
+	}
+
+	e := &encoded{
+		ctx:   &c,
+		salt:  salt,
+		hash:  hash,
+		extra: []param{{innerParam, inner}},
+	}
+	return e.String(), nil
+}
+
+// VerifyWrapped verifies an encoded string produced by WrapLegacy against a
+// plaintext password. Like VerifyEncoded, it rejects memory above
+// DefaultMaxMemory, and it rejects bcrypt costs above DefaultMaxBcryptCost.
+func VerifyWrapped(s string, password []byte) (bool, error) {
+	return verifyWrapped(nil, DefaultMaxBcryptCost, s, password)
+}
+
+func verifyWrapped(policy *Policy, maxBcryptCost int, s string, password []byte) (bool, error) {
+	if len(password) == 0 {
+		return false, ErrPassword
+	}
+
+	e, err := decode(s)
+	if err != nil {
+		return false, err
+	}
+	if len(e.extra) != 1 || e.extra[0].key != innerParam {
+		return false, ErrDecodingFail
+	}
+	if err := checkVerify(policy, e.ctx); err != nil {
+		return false, err
+	}
+
+	digest, err := legacyDigest(e.extra[0].value, password, maxBcryptCost)
+	if err != nil {
+		return false, err
+	}
+
+	return e.verify(digest)
+}
+
+// wrapped reports whether s is an encoded string produced by WrapLegacy.
+func wrapped(s string) bool {
+	e, err := decode(s)
+	if err != nil {
+		return false
+	}
+	for _, p := range e.extra {
+		if p.key == innerParam {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyDigest recomputes the legacy digest of password for the value of an
+// inner parameter, rejecting bcrypt costs above maxBcryptCost.
+func legacyDigest(inner string, password []byte, maxBcryptCost int) ([]byte, error) {
+	switch {
+	case inner == LegacyMD5:
+		d := md5.Sum(password)
+		return d[:], nil
+	case inner == LegacySHA1:
+		d := sha1.Sum(password)
+		return d[:], nil
+	case strings.HasPrefix(inner, LegacyBcrypt+"-"):
+		f := strings.SplitN(inner[len(LegacyBcrypt)+1:], "-", 3)
+		if len(f) != 3 {
+			return nil, ErrDecodingFail
+		}
+		cost, err := strconv.Atoi(f[1])
+		if err != nil {
+			return nil, ErrDecodingFail
+		}
+		if err := checkMax("cost", cost, maxBcryptCost); err != nil {
+			return nil, err
+		}
+		return bcryptHash(password, f[0], cost, f[2])
+	default:
+		return nil, ErrUnknownScheme
+	}
+}
+
+// bcrypt uses base64 with its own alphabet and no padding.
+var bcryptEncoding = base64.NewEncoding("./ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789").WithPadding(base64.NoPadding)
+
+// parseBcrypt splits a $2<minor>$<cost>$<salt><hash> string into its minor
+// version, cost and encoded salt.
+func parseBcrypt(s string) (minor string, cost int, salt string, err error) {
+	f := strings.Split(s, "$")
+	if len(f) != 4 || f[0] != "" || len(f[1]) != 2 || f[1][0] != '2' ||
+		len(f[2]) != 2 || len(f[3]) != 53 {
+		return "", 0, "", ErrDecodingFail
+	}
+	cost, err = strconv.Atoi(f[2])
+	if err != nil || cost < 4 || cost > 31 {
+		return "", 0, "", ErrDecodingFail
+	}
+	if _, err := bcryptEncoding.DecodeString(f[3][:22]); err != nil {
+		return "", 0, "", ErrDecodingFail
+	}
+	return f[1][1:], cost, f[3][:22], nil
+}
+
+// bcryptHash computes the full bcrypt string for password using the given
+// minor version, cost and encoded salt, as bcrypt.GenerateFromPassword would
+// have with that salt.
+func bcryptHash(password []byte, minor string, cost int, salt string) ([]byte, error) {
+	csalt, err := bcryptEncoding.DecodeString(salt)
+	if err != nil || len(salt) != 22 || len(minor) != 1 || cost < 4 || cost > 31 {
+		return nil, ErrDecodingFail
+	}
+
+	// C implementations include the trailing NUL of the key
+	key := append(append([]byte(nil), password...), 0)
+	c, err := blowfish.NewSaltedCipher(key, csalt)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < 1<<uint(cost); i++ {
+		blowfish.ExpandKey(key, c)
+		blowfish.ExpandKey(csalt, c)
+	}
+
+	data := []byte("OrpheanBeholderScryDoubt")
+	for i := 0; i < len(data); i += 8 {
+		for j := 0; j < 64; j++ {
+			c.Encrypt(data[i:i+8], data[i:i+8])
+		}
+	}
+
+	// Only 23 of the 24 encrypted bytes are encoded
+	s := fmt.Sprintf("$2%s$%02d$%s%s", minor, cost, salt, bcryptEncoding.EncodeToString(data[:23]))
+	return []byte(s), nil
+}
@@ -0,0 +1,134 @@
+package argon2
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestWrapLegacy(t *testing.T) {
+	md5sum := md5.Sum([]byte("password"))
+	sha1sum := sha1.Sum([]byte("password"))
+	bcrypted, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vectors := []struct {
+		scheme string
+		legacy string
+	}{
+		{LegacyMD5, hex.EncodeToString(md5sum[:])},
+		{LegacySHA1, hex.EncodeToString(sha1sum[:])},
+		{LegacyBcrypt, string(bcrypted)},
+	}
+
+	ctx := NewContext(ModeArgon2id)
+	v := NewVerifier(ctx)
+	for _, vec := range vectors {
+		s, err := WrapLegacy(ctx, vec.scheme, vec.legacy, []byte("somesalt"))
+		if err != nil {
+			t.Fatalf("WrapLegacy(%s): %s", vec.scheme, err)
+		}
+		if !strings.Contains(s, ",inner="+vec.scheme) {
+			t.Errorf("WrapLegacy(%s) = %q  missing inner parameter", vec.scheme, s)
+		}
+
+		ok, err := VerifyWrapped(s, []byte("password"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Errorf("VerifyWrapped(%q, password) = false  want true", s)
+		}
+
+		ok, err = VerifyWrapped(s, []byte("wrong"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			t.Errorf("VerifyWrapped(%q, wrong) = true  want false", s)
+		}
+
+		ok, rehash, err := v.Verify(s, []byte("password"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok || !rehash {
+			t.Errorf("Verifier.Verify(%q) = %t, %t  want true, true", s, ok, rehash)
+		}
+
+		if _, err := VerifyEncoded(s, []byte("password")); err == nil {
+			t.Errorf("VerifyEncoded(%q) accepted a wrapped hash", s)
+		}
+	}
+}
+
+func TestWrapLegacy_Unused(t *testing.T) {
+	ctx := NewContext(ModeArgon2id)
+	ctx.Secret, ctx.KeyID = []byte("pepper"), []byte("k1")
+	ctx.AssociatedData = []byte("ad")
+	ctx.Prehash = PrehashBLAKE2b512
+	sum := sha1.Sum([]byte("password"))
+
+	s, err := WrapLegacy(ctx, LegacySHA1, hex.EncodeToString(sum[:]), []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(s, "keyid=") || strings.Contains(s, "ph=") {
+		t.Errorf("WrapLegacy = %q  want no keyid or ph", s)
+	}
+	if ok, err := VerifyWrapped(s, []byte("password")); err != nil || !ok {
+		t.Errorf("VerifyWrapped(%q) = %v, %v  want true", s, ok, err)
+	}
+	if ok, _, err := NewVerifier(ctx).Verify(s, []byte("password")); err != nil || !ok {
+		t.Errorf("Verifier.Verify(%q) = %v, %v  want true", s, ok, err)
+	}
+}
+
+func TestVerifyWrapped_MaxMemory(t *testing.T) {
+	s := "$argon2id$v=19$m=4294967295,t=1,p=1,inner=md5$c29tZXNhbHQ$c29tZWhhc2g"
+	if ok, err := VerifyWrapped(s, []byte("password")); ok || !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("VerifyWrapped(%q) = %v, %v  want %v", s, ok, err, ErrInvalidParams)
+	}
+}
+
+func TestVerifyWrapped_MaxBcryptCost(t *testing.T) {
+	bcrypted, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := strings.Replace(string(bcrypted), "$04$", "$31$", 1)
+	s, err := WrapLegacy(NewContext(ModeArgon2id), LegacyBcrypt, legacy, []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyWrapped(s, []byte("password")); ok || !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("VerifyWrapped(%q) = %v, %v  want %v", s, ok, err, ErrInvalidParams)
+	}
+}
+
+func TestBcryptHash(t *testing.T) {
+	bcrypted, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	minor, cost, salt, err := parseBcrypt(string(bcrypted))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := bcryptHash([]byte("password"), minor, cost, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(s, bcrypted) {
+		t.Errorf("bcryptHash: got %q  want %q", s, bcrypted)
+	}
+}
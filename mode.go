@@ -0,0 +1,124 @@
+package argon2
+
+// #cgo CFLAGS: -I/usr/include
+// #include <argon2.h>
+import "C"
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Mode is an Argon2 variant. It implements flag.Value, so it can be set from
+// the command line by name.
+type Mode int
+
+const (
+	ModeArgon2d  Mode = C.Argon2_d
+	ModeArgon2i  Mode = C.Argon2_i
+	ModeArgon2id Mode = C.Argon2_id
+)
+
+var modeNames = map[Mode]string{
+	ModeArgon2d:  "argon2d",
+	ModeArgon2i:  "argon2i",
+	ModeArgon2id: "argon2id",
+}
+
+// ParseMode parses a mode name, such as "argon2id", ignoring case.
+func ParseMode(s string) (Mode, error) {
+	for m, name := range modeNames {
+		if strings.EqualFold(s, name) {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("argon2: unknown mode %q", s)
+}
+
+// String returns the name of the mode as used in encoded strings, such as
+// "argon2id".
+func (m Mode) String() string {
+	if name, ok := modeNames[m]; ok {
+		return name
+	}
+	return "Mode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// IsValid reports whether m is one of the Argon2 variants.
+func (m Mode) IsValid() bool {
+	_, ok := modeNames[m]
+	return ok
+}
+
+// Set parses s with ParseMode and implements flag.Value.
+func (m *Mode) Set(s string) error {
+	mode, err := ParseMode(s)
+	if err != nil {
+		return err
+	}
+	*m = mode
+	return nil
+}
+
+// Version is a version of the Argon2 algorithm. It implements flag.Value, so
+// it can be set from the command line.
+type Version int
+
+const (
+	Version10      Version = C.ARGON2_VERSION_10
+	Version13      Version = C.ARGON2_VERSION_13
+	VersionDefault Version = C.ARGON2_VERSION_NUMBER
+)
+
+var versionNames = map[Version]string{
+	Version10: "1.0",
+	Version13: "1.3",
+}
+
+// ParseVersion parses a version given as "1.0" or "1.3", in hexadecimal as
+// "0x10" or "0x13", or in decimal as "16" or "19". Other forms accepted by
+// strconv, such as leading zeros, octal or underscores, are rejected.
+func ParseVersion(s string) (Version, error) {
+	for v, name := range versionNames {
+		if s == name {
+			return v, nil
+		}
+	}
+
+	digits, base := s, 10
+	if strings.HasPrefix(s, "0x") {
+		digits, base = s[len("0x"):], 16
+	}
+	if len(digits) > 0 && digits[0] != '0' {
+		n, err := strconv.ParseUint(digits, base, 8)
+		if v := Version(n); err == nil && v.IsValid() {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("argon2: unknown version %q", s)
+}
+
+// String returns the version as "1.0" or "1.3".
+func (v Version) String() string {
+	if name, ok := versionNames[v]; ok {
+		return name
+	}
+	return "Version(" + strconv.Itoa(int(v)) + ")"
+}
+
+// IsValid reports whether v is Version10 or Version13.
+func (v Version) IsValid() bool {
+	_, ok := versionNames[v]
+	return ok
+}
+
+// Set parses s with ParseVersion and implements flag.Value.
+func (v *Version) Set(s string) error {
+	version, err := ParseVersion(s)
+	if err != nil {
+		return err
+	}
+	*v = version
+	return nil
+}
@@ -0,0 +1,91 @@
+package argon2
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+)
+
+func TestMode(t *testing.T) {
+	vectors := []struct {
+		mode Mode
+		name string
+	}{
+		{ModeArgon2d, "argon2d"},
+		{ModeArgon2i, "argon2i"},
+		{ModeArgon2id, "argon2id"},
+	}
+
+	for _, v := range vectors {
+		if s := v.mode.String(); s != v.name {
+			t.Errorf("%d: String() = %q  want %q", v.mode, s, v.name)
+		}
+		if !v.mode.IsValid() {
+			t.Errorf("%s: IsValid() = false", v.name)
+		}
+		if m, err := ParseMode(v.name); err != nil || m != v.mode {
+			t.Errorf("ParseMode(%q) = %v, %v", v.name, m, err)
+		}
+	}
+
+	if m, err := ParseMode("Argon2ID"); err != nil || m != ModeArgon2id {
+		t.Errorf("ParseMode is case-sensitive: %v, %v", m, err)
+	}
+	if _, err := ParseMode("argon2"); err == nil {
+		t.Error("ParseMode(\"argon2\"): expected error")
+	}
+	if m := Mode(99); m.IsValid() || m.String() != "Mode(99)" {
+		t.Errorf("Mode(99): IsValid() = %v, String() = %q", m.IsValid(), m.String())
+	}
+}
+
+func TestVersion(t *testing.T) {
+	vectors := []struct {
+		in      string
+		version Version
+	}{
+		{"1.0", Version10},
+		{"1.3", Version13},
+		{"0x10", Version10},
+		{"0x13", Version13},
+		{"16", Version10},
+		{"19", Version13},
+	}
+
+	for _, v := range vectors {
+		if version, err := ParseVersion(v.in); err != nil || version != v.version {
+			t.Errorf("ParseVersion(%q) = %v, %v  want %v", v.in, version, err, v.version)
+		}
+	}
+	for _, in := range []string{"", "1.2", "13", "0x14", "256", "020", "019", "0b10011", "0o23", "1_9", "0x1_3", "0X13", "0x013", "+19", "0x"} {
+		if _, err := ParseVersion(in); err == nil {
+			t.Errorf("ParseVersion(%q): expected error", in)
+		}
+	}
+
+	if Version13.String() != "1.3" || VersionDefault != Version13 {
+		t.Errorf("Version13 = %v, VersionDefault = %v", Version13, VersionDefault)
+	}
+	if v := Version(0); v.IsValid() || v.String() != "Version(0)" {
+		t.Errorf("Version(0): IsValid() = %v, String() = %q", v.IsValid(), v.String())
+	}
+}
+
+func TestModeFlag(t *testing.T) {
+	mode, version := ModeArgon2i, VersionDefault
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Var(&mode, "mode", "")
+	fs.Var(&version, "version", "")
+
+	if err := fs.Parse([]string{"-mode", "argon2id", "-version", "0x10"}); err != nil {
+		t.Fatal(err)
+	}
+	if mode != ModeArgon2id || version != Version10 {
+		t.Errorf("got %v, %v", mode, version)
+	}
+
+	if err := fs.Parse([]string{"-mode", "argon3"}); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
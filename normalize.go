@@ -0,0 +1,124 @@
+package argon2
+
+import (
+	"strconv"
+	"unicode/utf8"
+
+	"golang.org/x/text/secure/precis"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalization is a Unicode normalization applied to passwords before they
+// are hashed, so that the same password typed on different keyboards or
+// operating systems produces the same hash. It is recorded in encoded strings
+// as the "norm" parameter, for example:
+//
+//	$argon2id$v=19$m=65536,t=3,p=4,norm=nfkc$<salt>$<hash>
+//
+// so that verification applies the same normalization.
+type Normalization int
+
+const (
+	NormalizationNone         Normalization = iota // passwords are hashed as-is
+	NormalizationNFKC                              // Unicode NFKC
+	NormalizationOpaqueString                      // RFC 8265 OpaqueString (PRECIS)
+)
+
+// normParam is the encoded string parameter naming the normalization.
+const normParam = "norm"
+
+var normalizationNames = map[Normalization]string{
+	NormalizationNFKC:         "nfkc",
+	NormalizationOpaqueString: "opaque",
+}
+
+// String returns the name of the normalization as recorded in encoded
+// strings, or "none".
+func (n Normalization) String() string {
+	if name, ok := normalizationNames[n]; ok {
+		return name
+	}
+	if n == NormalizationNone {
+		return "none"
+	}
+	return "Normalization(" + strconv.Itoa(int(n)) + ")"
+}
+
+// parseNormalization parses the value of a norm parameter.
+func parseNormalization(s string) (Normalization, bool) {
+	for n, name := range normalizationNames {
+		if s == name {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// transformsPassword reports whether prepare may change passwords for ctx.
+func (ctx *Context) transformsPassword() bool {
+	return ctx.Normalization != NormalizationNone || ctx.Prehash != PrehashNone
+}
+
+// prepare applies the normalization of ctx to password, checks its length in
+// characters against MinPasswordLen and MaxPasswordLen, and pre-hashes it if
+// it is too long. Intermediate copies are wiped; if the result is a copy, the
+// caller must wipe it with release once it has been hashed. If
+// FlagClearPassword is set and the result is a copy, the original password is
+// cleared, as libargon2 only clears the copy it is given.
+func (ctx *Context) prepare(password []byte) ([]byte, error) {
+	if len(password) == 0 {
+		return nil, ErrPassword
+	}
+
+	p := password
+	switch ctx.Normalization {
+	case NormalizationNone:
+	case NormalizationNFKC:
+		p = norm.NFKC.Bytes(password)
+	case NormalizationOpaqueString:
+		var err error
+		if p, err = precis.OpaqueString.Bytes(password); err != nil {
+			return nil, ErrNormalization
+		}
+	default:
+		return nil, ErrNormalization
+	}
+
+	n := utf8.RuneCount(p)
+	var err error
+	if ctx.MinPasswordLen > 0 && n < ctx.MinPasswordLen {
+		err = ErrPasswordTooShort
+	} else if ctx.MaxPasswordLen > 0 && n > ctx.MaxPasswordLen {
+		err = ErrPasswordTooLong
+	}
+
+	var d []byte
+	if err == nil {
+		d, err = ctx.prehash(p)
+	}
+	if err != nil {
+		release(p, password)
+		return nil, err
+	}
+	if &d[0] != &p[0] {
+		release(p, password)
+	}
+
+	if ctx.Flags&FlagClearPassword != 0 && &d[0] != &password[0] {
+		zero(password)
+	}
+	return d, nil
+}
+
+// release wipes p if it is a copy of password made by prepare.
+func release(p, password []byte) {
+	if len(p) > 0 && &p[0] != &password[0] {
+		zero(p)
+	}
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,107 @@
+package argon2
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalization(t *testing.T) {
+	vectors := []struct {
+		norm     Normalization
+		password string
+		other    string // same password as typed elsewhere
+	}{
+		{NormalizationNFKC, "caf\u00e9", "cafe\u0301"},
+		{NormalizationNFKC, "\ufb01sh", "fish"},
+		{NormalizationOpaqueString, "caf\u00e9", "cafe\u0301"},
+		{NormalizationOpaqueString, "pass word", "pass\u00a0word"},
+	}
+
+	for _, v := range vectors {
+		ctx := NewContext(ModeArgon2id)
+		ctx.Normalization = v.norm
+		ctx.SaltLen = 8
+
+		s, err := HashEncoded(ctx, []byte(v.password), []byte("somesalt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(s, ",norm="+v.norm.String()+"$") {
+			t.Errorf("%s: normalization not recorded in %q", v.norm, s)
+		}
+
+		ok, err := VerifyEncoded(s, []byte(v.other))
+		if err != nil || !ok {
+			t.Errorf("%s: VerifyEncoded(%q) = %v, %v  want true", v.norm, v.other, ok, err)
+		}
+		if ok, _ := VerifyEncoded(s, []byte("fish")); ok && v.other != "fish" {
+			t.Errorf("%s: VerifyEncoded accepted wrong password", v.norm)
+		}
+
+		dctx, _, _, err := Decode(s)
+		if err != nil || dctx.Normalization != v.norm {
+			t.Errorf("%s: Decode = %+v, %v", v.norm, dctx, err)
+		}
+		if NeedsRehash(ctx, s) {
+			t.Errorf("%s: NeedsRehash = true  want false", v.norm)
+		}
+		plain := NewContext(ModeArgon2id)
+		plain.SaltLen = 8
+		if !NeedsRehash(plain, s) {
+			t.Errorf("%s: NeedsRehash without normalization = false  want true", v.norm)
+		}
+
+		h1, err := Hash(ctx, []byte(v.password), []byte("somesalt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		h2, err := Hash(ctx, []byte(v.other), []byte("somesalt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(h1, h2) {
+			t.Errorf("%s: Hash differs for %q and %q", v.norm, v.password, v.other)
+		}
+	}
+}
+
+func TestNormalization_Error(t *testing.T) {
+	ctx := NewContext()
+	ctx.Normalization = NormalizationOpaqueString
+	if _, err := Hash(ctx, []byte("pass\x07word"), []byte("somesalt")); !errors.Is(err, ErrNormalization) {
+		t.Errorf("control character: got %v  want %v", err, ErrNormalization)
+	}
+
+	// seven characters in nine bytes
+	ctx = NewContext()
+	ctx.MinPasswordLen = 8
+	if _, err := HashEncoded(ctx, []byte("pässwör"), []byte("somesalt")); !errors.Is(err, ErrPasswordTooShort) {
+		t.Errorf("MinPasswordLen: got %v  want %v", err, ErrPasswordTooShort)
+	}
+	ctx.MinPasswordLen = 7
+	if _, err := HashEncoded(ctx, []byte("pässwör"), []byte("somesalt")); err != nil {
+		t.Errorf("MinPasswordLen: got %v  want nil", err)
+	}
+
+	ctx = NewContext()
+	ctx.MaxPasswordLen = 4
+	if ok, err := Verify(ctx, []byte{1}, []byte("password"), []byte("somesalt")); ok || !errors.Is(err, ErrPasswordTooLong) {
+		t.Errorf("MaxPasswordLen: got %v, %v  want %v", ok, err, ErrPasswordTooLong)
+	}
+}
+
+func TestNormalization_ClearPassword(t *testing.T) {
+	ctx := NewContext()
+	ctx.Normalization = NormalizationNFKC
+	ctx.Flags = FlagClearPassword
+
+	password := []byte("café")
+	if _, err := Hash(ctx, password, []byte("somesalt")); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(make([]byte, len(password)), password) {
+		t.Fatalf("password slice is not cleared")
+	}
+}
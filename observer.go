@@ -0,0 +1,214 @@
+package argon2
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Outcome is the result of an observed operation.
+type Outcome int
+
+const (
+	OutcomeOK       Outcome = iota // hashed, or the password matched
+	OutcomeMismatch                // the password did not match
+	OutcomeError                   // the operation failed
+)
+
+var outcomeNames = map[Outcome]string{
+	OutcomeOK:       "ok",
+	OutcomeMismatch: "mismatch",
+	OutcomeError:    "error",
+}
+
+func (o Outcome) String() string {
+	if s, ok := outcomeNames[o]; ok {
+		return s
+	}
+	return "Outcome(" + strconv.Itoa(int(o)) + ")"
+}
+
+// Event describes one call to Hash, HashEncoded, Verify or VerifyEncoded, or
+// one item of HashBatch. The parameters are those used for hashing; for
+// VerifyEncoded they are decoded from the encoded string and are zero if it
+// was not decoded.
+type Event struct {
+	Op          string          // "Hash", "HashEncoded", "HashBatch", "Verify" or "VerifyEncoded"
+	Context     context.Context // the context returned by StartObserver.Start, or that of the caller
+	Mode        Mode
+	Memory      int // in KiB
+	Iterations  int
+	Parallelism int
+	Duration    time.Duration
+	Outcome     Outcome
+	Code        Error // libargon2 error code, or 0 if there is none
+	Err         error // nil unless Outcome is OutcomeError
+}
+
+// An Observer is notified after every hash and verification. Observe is
+// called synchronously, possibly from several goroutines at once, so it
+// should return quickly.
+type Observer interface {
+	Observe(e Event)
+}
+
+// A StartObserver is an Observer that is also notified when an operation
+// starts, for example to start a trace span. Start is passed the context of
+// the caller, which is that of the Hasher method for a CgoHasher and
+// context.Background() for the package-level functions. The context it
+// returns is passed to Observe in Event.Context, where the span can be ended:
+//
+//	func (t tracer) Start(ctx context.Context, op string) context.Context {
+//		ctx, _ = t.tracer.Start(ctx, "argon2."+op)
+//		return ctx
+//	}
+//
+//	func (t tracer) Observe(e argon2.Event) {
+//		span := trace.SpanFromContext(e.Context)
+//		if e.Err != nil {
+//			span.SetStatus(codes.Error, e.Err.Error())
+//		}
+//		span.End()
+//	}
+type StartObserver interface {
+	Observer
+	Start(ctx context.Context, op string) context.Context
+}
+
+// ObserverFunc adapts a function to the Observer interface.
+type ObserverFunc func(e Event)
+
+func (f ObserverFunc) Observe(e Event) {
+	f(e)
+}
+
+type observerBox struct {
+	o Observer
+}
+
+var observer atomic.Value // observerBox
+
+// SetObserver sets the Observer notified by Hash, HashEncoded, HashBatch,
+// Verify and VerifyEncoded. A nil o disables observation, which is the default.
+func SetObserver(o Observer) {
+	observer.Store(observerBox{o})
+}
+
+func loadObserver() Observer {
+	b, _ := observer.Load().(observerBox)
+	return b.o
+}
+
+// observation is an operation in progress, started by begin.
+type observation struct {
+	o     Observer // nil if there is no Observer to notify
+	op    string
+	ctx   context.Context
+	start time.Time
+}
+
+// begin starts the observation of operation op, called with ctx.
+func begin(ctx context.Context, op string) observation {
+	o := loadObserver()
+	if o == nil {
+		return observation{}
+	}
+	if s, ok := o.(StartObserver); ok {
+		ctx = s.Start(ctx, op)
+	}
+	return observation{o, op, ctx, time.Now()}
+}
+
+// end reports the operation, with the parameters in ctx, to the Observer
+// that was set when it began.
+func (ob observation) end(ctx *Context, ok bool, err error) {
+	if ob.o == nil {
+		return
+	}
+
+	e := Event{Op: ob.op, Context: ob.ctx, Duration: time.Since(ob.start)}
+	if ctx != nil {
+		e.Mode = ctx.Mode
+		e.Memory = ctx.Memory
+		e.Iterations = ctx.Iterations
+		e.Parallelism = ctx.Parallelism
+	}
+	switch {
+	case err != nil:
+		e.Outcome, e.Err = OutcomeError, err
+		errors.As(err, &e.Code)
+	case !ok:
+		e.Outcome = OutcomeMismatch
+	}
+	ob.o.Observe(e)
+}
+
+// NewExpvarObserver returns an Observer that maintains counters in m. For
+// each operation and outcome it adds to "<op>.<outcome>", and for each
+// operation it accumulates "<op>.seconds" and "<op>.memory_kib", from which
+// average latency and memory can be derived:
+//
+//	argon2.SetObserver(argon2.NewExpvarObserver(expvar.NewMap("argon2")))
+func NewExpvarObserver(m *expvar.Map) Observer {
+	return ObserverFunc(func(e Event) {
+		m.Add(e.Op+"."+e.Outcome.String(), 1)
+		m.AddFloat(e.Op+".seconds", e.Duration.Seconds())
+		m.Add(e.Op+".memory_kib", int64(e.Memory))
+	})
+}
+
+// Attribute is a key-value pair attached to a measurement.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// Float64Histogram records measurements. It has the shape of an
+// OpenTelemetry metric.Float64Histogram, so adapting one takes a few lines:
+//
+//	type histogram struct{ h metric.Float64Histogram }
+//
+//	func (h histogram) Record(ctx context.Context, v float64, attrs ...argon2.Attribute) {
+//		kv := make([]attribute.KeyValue, len(attrs))
+//		for i, a := range attrs {
+//			kv[i] = attribute.String(a.Key, a.Value)
+//		}
+//		h.h.Record(ctx, v, metric.WithAttributes(kv...))
+//	}
+type Float64Histogram interface {
+	Record(ctx context.Context, value float64, attrs ...Attribute)
+}
+
+// NewMetricsObserver returns an Observer that records the duration of each
+// operation in seconds to duration, and its memory in KiB to memory, with the
+// context in Event.Context. Either may be nil. Measurements carry the
+// attributes argon2.operation, argon2.mode and argon2.outcome, plus
+// error.code for failures. The memory, iterations and parallelism of
+// VerifyEncoded come from the encoded string, which may take any value, so
+// they are not attributes.
+func NewMetricsObserver(duration, memory Float64Histogram) Observer {
+	return ObserverFunc(func(e Event) {
+		attrs := []Attribute{
+			{"argon2.operation", e.Op},
+			{"argon2.mode", e.Mode.String()},
+			{"argon2.outcome", e.Outcome.String()},
+		}
+		if e.Outcome == OutcomeError {
+			attrs = append(attrs, Attribute{"error.code", strconv.Itoa(int(e.Code))})
+		}
+
+		ctx := e.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if duration != nil {
+			duration.Record(ctx, e.Duration.Seconds(), attrs...)
+		}
+		if memory != nil {
+			memory.Record(ctx, float64(e.Memory), attrs...)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package argon2
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// recorder is an in-memory Observer and Float64Histogram.
+type recorder struct {
+	mu      sync.Mutex
+	events  []Event
+	samples []sample
+}
+
+type sample struct {
+	ctx   context.Context
+	value float64
+	attrs map[string]string
+}
+
+func (r *recorder) Observe(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) Record(ctx context.Context, value float64, attrs ...Attribute) {
+	m := make(map[string]string, len(attrs))
+	for _, a := range attrs {
+		m[a.Key] = a.Value
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples = append(r.samples, sample{ctx, value, m})
+}
+
+func withObserver(t *testing.T, o Observer) {
+	SetObserver(o)
+	t.Cleanup(func() { SetObserver(nil) })
+}
+
+func TestObserver(t *testing.T) {
+	r := new(recorder)
+	withObserver(t, r)
+
+	ctx := NewContext(ModeArgon2id)
+	password, salt := []byte("password"), []byte("somesalt")
+	hash, _ := Hash(ctx, password, salt)
+	Verify(ctx, hash, []byte("wrong"), salt)
+	s, _ := HashEncoded(ctx, password, salt)
+	VerifyEncoded(s, password)
+	Hash(ctx, password, []byte("s"))
+	VerifyEncoded("$argon2id$v=19$bogus", password)
+
+	want := []struct {
+		op      string
+		outcome Outcome
+		code    Error
+	}{
+		{"Hash", OutcomeOK, 0},
+		{"Verify", OutcomeMismatch, 0},
+		{"HashEncoded", OutcomeOK, 0},
+		{"VerifyEncoded", OutcomeOK, 0},
+		{"Hash", OutcomeError, ErrSaltTooShort},
+		{"VerifyEncoded", OutcomeError, ErrDecodingFail},
+	}
+	if len(r.events) != len(want) {
+		t.Fatalf("got %d events  want %d", len(r.events), len(want))
+	}
+	for i, w := range want {
+		e := r.events[i]
+		if e.Op != w.op || e.Outcome != w.outcome || e.Code != w.code {
+			t.Errorf("%d: got %s %s %d  want %s %s %d", i, e.Op, e.Outcome, e.Code, w.op, w.outcome, w.code)
+		}
+		if (e.Err != nil) != (w.outcome == OutcomeError) {
+			t.Errorf("%d: Err = %v", i, e.Err)
+		}
+	}
+
+	e := r.events[3]
+	if e.Mode != ModeArgon2id || e.Memory != ctx.Memory || e.Iterations != ctx.Iterations || e.Parallelism != ctx.Parallelism {
+		t.Errorf("VerifyEncoded parameters: got %+v", e)
+	}
+	if e.Duration <= 0 {
+		t.Errorf("Duration = %v", e.Duration)
+	}
+	if e := r.events[5]; e.Memory != 0 || !errors.Is(e.Err, ErrMalformedEncoding) {
+		t.Errorf("malformed VerifyEncoded: got %+v", e)
+	}
+
+	SetObserver(nil)
+	Hash(ctx, password, salt)
+	if len(r.events) != len(want) {
+		t.Errorf("observed with nil Observer")
+	}
+}
+
+func TestExpvarObserver(t *testing.T) {
+	m := new(expvar.Map).Init()
+	withObserver(t, NewExpvarObserver(m))
+
+	ctx := NewContext()
+	password, salt := []byte("password"), []byte("somesalt")
+	hash, _ := Hash(ctx, password, salt)
+	Verify(ctx, hash, password, salt)
+	Verify(ctx, hash, []byte("wrong"), salt)
+
+	for key, want := range map[string]string{
+		"Hash.ok":           "1",
+		"Verify.ok":         "1",
+		"Verify.mismatch":   "1",
+		"Verify.memory_kib": "8192",
+	} {
+		if v := m.Get(key); v == nil || v.String() != want {
+			t.Errorf("%s = %v  want %s", key, v, want)
+		}
+	}
+	if v, ok := m.Get("Verify.seconds").(*expvar.Float); !ok || v.Value() <= 0 {
+		t.Errorf("Verify.seconds = %v", m.Get("Verify.seconds"))
+	}
+}
+
+func TestMetricsObserver(t *testing.T) {
+	duration, memory := new(recorder), new(recorder)
+	withObserver(t, NewMetricsObserver(duration, memory))
+
+	ctx := NewContext(ModeArgon2i)
+	Hash(ctx, []byte("password"), []byte("somesalt"))
+	Hash(ctx, []byte("password"), []byte("s"))
+
+	if len(duration.samples) != 2 || len(memory.samples) != 2 {
+		t.Fatalf("got %d duration and %d memory samples  want 2", len(duration.samples), len(memory.samples))
+	}
+	s := duration.samples[0]
+	if s.value <= 0 || s.attrs["argon2.operation"] != "Hash" || s.attrs["argon2.mode"] != "argon2i" ||
+		s.attrs["argon2.outcome"] != "ok" {
+		t.Errorf("duration sample: got %+v", s)
+	}
+	if _, ok := s.attrs["error.code"]; ok {
+		t.Errorf("error.code recorded for success")
+	}
+	if s := memory.samples[1]; s.value != 4096 || s.attrs["argon2.outcome"] != "error" || s.attrs["error.code"] != "-6" {
+		t.Errorf("memory sample: got %+v", s)
+	}
+
+	// parameters of encoded strings are not attributes, as they are unbounded
+	for _, a := range []string{"argon2.memory", "argon2.iterations", "argon2.parallelism"} {
+		if _, ok := s.attrs[a]; ok {
+			t.Errorf("%s recorded", a)
+		}
+	}
+
+	// measurements are recorded with the context of the caller
+	ctx2 := context.WithValue(context.Background(), traceKey{}, "caller")
+	DefaultHasher.Hash(ctx2, ctx, []byte("password"), []byte("somesalt"))
+	if s := duration.samples[2]; s.ctx.Value(traceKey{}) != "caller" {
+		t.Errorf("recorded with context %v", s.ctx)
+	}
+}
+
+type traceKey struct{}
+
+// tracer is a StartObserver that records the contexts of started and
+// observed operations.
+type tracer struct {
+	recorder
+	started []string
+}
+
+func (r *tracer) Start(ctx context.Context, op string) context.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.started = append(r.started, op)
+	return context.WithValue(ctx, traceKey{}, fmt.Sprintf("%v/%s", ctx.Value(traceKey{}), op))
+}
+
+func TestStartObserver(t *testing.T) {
+	r := new(tracer)
+	withObserver(t, r)
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "caller")
+	params := NewContext(ModeArgon2id)
+	password, salt := []byte("password"), []byte("somesalt")
+	s, _ := DefaultHasher.HashEncoded(ctx, params, password, salt)
+	DefaultHasher.VerifyEncoded(ctx, s, password)
+	Hash(params, password, salt)
+
+	if want := []string{"HashEncoded", "VerifyEncoded", "Hash"}; fmt.Sprint(r.started) != fmt.Sprint(want) {
+		t.Errorf("started %v  want %v", r.started, want)
+	}
+	if len(r.events) != 3 {
+		t.Fatalf("got %d events  want 3", len(r.events))
+	}
+	for i, want := range []string{"caller/HashEncoded", "caller/VerifyEncoded", "<nil>/Hash"} {
+		if got := r.events[i].Context.Value(traceKey{}); got != want {
+			t.Errorf("%d: got context %v  want %v", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package argon2
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// PasswordHash is a validated Argon2 encoded string. It implements the
+// database/sql Scanner and driver Valuer interfaces, and marshals to and from
+// text and JSON, so hashes can be persisted without hand-written
+// conversions. The zero value represents a missing hash and is stored as
+// NULL.
+type PasswordHash struct {
+	s    string
+	ctx  *Context
+	salt []byte
+}
+
+// ParsePasswordHash validates an encoded string, as produced by HashEncoded,
+// and returns it as a PasswordHash.
+func ParsePasswordHash(s string) (PasswordHash, error) {
+	ctx, salt, _, err := Decode(s)
+	if err != nil {
+		return PasswordHash{}, err
+	}
+	return PasswordHash{s: s, ctx: ctx, salt: salt}, nil
+}
+
+// String returns the encoded string, or "" for the zero value.
+func (h PasswordHash) String() string {
+	return h.s
+}
+
+// IsZero reports whether h is the zero value.
+func (h PasswordHash) IsZero() bool {
+	return h.s == ""
+}
+
+// Params returns a context holding the parameters the hash was computed
+// with, or nil for the zero value.
+func (h PasswordHash) Params() *Context {
+	if h.ctx == nil {
+		return nil
+	}
+	ctx := *h.ctx
+	return &ctx
+}
+
+// Salt returns the salt the hash was computed with.
+func (h PasswordHash) Salt() []byte {
+	return append([]byte(nil), h.salt...)
+}
+
+// Verify verifies the hash against a plaintext password.
+func (h PasswordHash) Verify(password []byte) (bool, error) {
+	if h.IsZero() {
+		return false, ErrHash
+	}
+	return VerifyEncoded(h.s, password)
+}
+
+// Scan implements the sql.Scanner interface. It accepts string, []byte and
+// nil values.
+func (h *PasswordHash) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*h = PasswordHash{}
+		return nil
+	case string:
+		return h.UnmarshalText([]byte(v))
+	case []byte:
+		return h.UnmarshalText(v)
+	default:
+		return fmt.Errorf("argon2: cannot scan %T into PasswordHash", src)
+	}
+}
+
+// Value implements the driver.Valuer interface.
+func (h PasswordHash) Value() (driver.Value, error) {
+	if h.IsZero() {
+		return nil, nil
+	}
+	return h.s, nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (h PasswordHash) MarshalText() ([]byte, error) {
+	return []byte(h.s), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface. Empty
+// text results in the zero value.
+func (h *PasswordHash) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*h = PasswordHash{}
+		return nil
+	}
+
+	p, err := ParsePasswordHash(string(text))
+	if err != nil {
+		return err
+	}
+	*h = p
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface. The zero value is
+// marshaled as null.
+func (h PasswordHash) MarshalJSON() ([]byte, error) {
+	if h.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(h.s)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (h *PasswordHash) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*h = PasswordHash{}
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return h.UnmarshalText([]byte(s))
+}
@@ -0,0 +1,74 @@
+package argon2
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestPasswordHash(t *testing.T) {
+	s, err := HashEncoded(NewContext(ModeArgon2id), []byte("password"), []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var h PasswordHash
+	if err := h.Scan([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if h.String() != s {
+		t.Errorf("String() = %q  want %q", h.String(), s)
+	}
+	if ctx := h.Params(); ctx.Mode != ModeArgon2id || ctx.Memory != 1<<12 || ctx.HashLen != 32 {
+		t.Errorf("Params() = %+v", ctx)
+	}
+	if v, err := h.Value(); err != nil || v != s {
+		t.Errorf("Value() = %v, %v  want %q", v, err, s)
+	}
+
+	ok, err := h.Verify([]byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Errorf("Verify(password) = false  want true")
+	}
+
+	data, err := json.Marshal(struct{ Hash PasswordHash }{h})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v struct{ Hash PasswordHash }
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Hash.String() != s {
+		t.Errorf("json round trip: got %q  want %q", v.Hash, s)
+	}
+}
+
+func TestPasswordHash_Null(t *testing.T) {
+	var h PasswordHash
+	if err := h.Scan(nil); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := h.Value(); err != nil || v != nil {
+		t.Errorf("Value() = %v, %v  want nil", v, err)
+	}
+	if data, _ := json.Marshal(h); string(data) != "null" {
+		t.Errorf("MarshalJSON() = %s  want null", data)
+	}
+	if _, err := h.Verify([]byte("password")); !errors.Is(err, ErrHash) {
+		t.Errorf("Verify: got %v  want %v", err, ErrHash)
+	}
+}
+
+func TestPasswordHash_Invalid(t *testing.T) {
+	var h PasswordHash
+	if err := h.Scan("$argon2id$v=19$m=4096"); !errors.Is(err, ErrDecodingFail) {
+		t.Errorf("Scan: got %v  want %v", err, ErrDecodingFail)
+	}
+	if err := h.Scan(42); err == nil {
+		t.Errorf("Scan(42): got nil error")
+	}
+}
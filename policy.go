@@ -0,0 +1,142 @@
+package argon2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxMemory is the maximum memory usage, in KiB, of encoded hashes
+// verified without a Policy or with a zero MaxMemory. Encoded strings may
+// ask for up to 4 TiB, which would exhaust the memory of the process.
+const DefaultMaxMemory = 1 << 22 // 4 GiB
+
+// Policy describes the parameters allowed in encoded hashes. Its minimums
+// describe the strength required of stored hashes, for example to audit a
+// table of hashes or to decide which need to be upgraded. Its maximums bound
+// the cost of verification: encoded strings from a tampered database row or
+// from a client can ask for any amount of memory and time, and
+// Policy.VerifyEncoded rejects them before hashing. Zero fields are not
+// checked, except that verification limits memory to DefaultMaxMemory if
+// MaxMemory is zero.
+type Policy struct {
+	Modes         []Mode    // allowed modes (any if empty)
+	Versions      []Version // allowed versions (any if empty)
+	MinVersion    Version   // minimum version, e.g. Version13
+	MinMemory     int       // minimum memory usage in KiB
+	MaxMemory     int       // maximum memory usage in KiB (DefaultMaxMemory when verifying if zero)
+	MinIterations int       // minimum number of iterations
+	MaxIterations int       // maximum number of iterations
+	MaxLanes      int       // maximum degree of parallelism
+	MinHashLen    int       // minimum hash output length
+	MinSaltLen    int       // minimum salt length
+}
+
+// PolicyError describes a parameter of an encoded hash that violates a
+// policy.
+type PolicyError struct {
+	Param  string // name of the offending parameter, e.g. "m" or "salt"
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("argon2: policy violation: %s", e.Reason)
+}
+
+// Is reports whether target is ErrInvalidParams, the category of policy
+// violations.
+func (e *PolicyError) Is(target error) bool {
+	return target == ErrInvalidParams
+}
+
+// Check decodes the encoded hash s and reports whether it meets the policy.
+// It returns nil, a *PolicyError describing the first violation, or an error
+// if s cannot be decoded.
+func (p *Policy) Check(s string) error {
+	e, err := decode(s)
+	if err != nil {
+		return err
+	}
+
+	for _, x := range e.extra {
+		if x.key == innerParam {
+			return &PolicyError{innerParam, "wraps legacy scheme " + strings.SplitN(x.value, "-", 2)[0]}
+		}
+	}
+
+	return p.check(e.ctx)
+}
+
+// VerifyEncoded is like the package-level VerifyEncoded, but returns a
+// *PolicyError without hashing if s violates the policy.
+func (p *Policy) VerifyEncoded(s string, password []byte) (bool, error) {
+	return p.VerifyEncodedWith(nil, s, password)
+}
+
+// VerifyEncodedWith is like the package-level VerifyEncodedWith, but returns
+// a *PolicyError without hashing if s violates the policy.
+func (p *Policy) VerifyEncodedWith(ctx *Context, s string, password []byte) (bool, error) {
+	o := begin(context.Background(), "VerifyEncoded")
+	params, ok, err := verifyEncoded(ctx, p, s, password)
+	o.end(params, ok, err)
+	return ok, err
+}
+
+// checkVerify checks the parameters of a hash that is about to be verified
+// against policy, which may be nil. Memory is always bounded, by
+// DefaultMaxMemory if the policy does not bound it.
+func checkVerify(policy *Policy, ctx *Context) error {
+	p := Policy{}
+	if policy != nil {
+		p = *policy
+	}
+	if p.MaxMemory == 0 {
+		p.MaxMemory = DefaultMaxMemory
+	}
+	return p.check(ctx)
+}
+
+func (p *Policy) check(ctx *Context) error {
+	if len(p.Modes) > 0 {
+		allowed := false
+		for _, m := range p.Modes {
+			allowed = allowed || m == ctx.Mode
+		}
+		if !allowed {
+			return &PolicyError{"mode", fmt.Sprintf("mode %s not allowed", ctx.Mode)}
+		}
+	}
+	if len(p.Versions) > 0 {
+		allowed := false
+		for _, v := range p.Versions {
+			allowed = allowed || v == ctx.Version
+		}
+		if !allowed {
+			return &PolicyError{"v", fmt.Sprintf("version %s not allowed", ctx.Version)}
+		}
+	}
+
+	checks := []struct {
+		param    string
+		value    int
+		min, max int
+		unit     string
+	}{
+		{"v", int(ctx.Version), int(p.MinVersion), 0, ""},
+		{"m", ctx.Memory, p.MinMemory, p.MaxMemory, " KiB"},
+		{"t", ctx.Iterations, p.MinIterations, p.MaxIterations, ""},
+		{"p", ctx.Parallelism, 0, p.MaxLanes, ""},
+		{"hash", ctx.HashLen, p.MinHashLen, 0, " bytes"},
+		{"salt", ctx.SaltLen, p.MinSaltLen, 0, " bytes"},
+	}
+	for _, c := range checks {
+		if c.value < c.min {
+			return &PolicyError{c.param, fmt.Sprintf("%s=%d%s below minimum %d%s", c.param, c.value, c.unit, c.min, c.unit)}
+		}
+		if c.max > 0 && c.value > c.max {
+			return &PolicyError{c.param, fmt.Sprintf("%s=%d%s above maximum %d%s", c.param, c.value, c.unit, c.max, c.unit)}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package argon2
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	s, err := HashEncoded(NewContext(ModeArgon2i), []byte("password"), []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vectors := []struct {
+		policy Policy
+		param  string
+	}{
+		{Policy{}, ""},
+		{Policy{Modes: []Mode{ModeArgon2i, ModeArgon2id}, MinMemory: 1 << 12, MinIterations: 3}, ""},
+		{Policy{Modes: []Mode{ModeArgon2id}}, "mode"},
+		{Policy{MinVersion: Version13}, ""},
+		{Policy{MinMemory: 1 << 16}, "m"},
+		{Policy{MinIterations: 4}, "t"},
+		{Policy{MinHashLen: 64}, "hash"},
+		{Policy{MinSaltLen: 16}, "salt"},
+		{Policy{Versions: []Version{Version13}, MinMemory: 1 << 12, MaxMemory: 1 << 12, MinIterations: 3, MaxIterations: 3}, ""},
+		{Policy{MinMemory: 1 << 10, MaxMemory: 1 << 11}, "m"},
+		{Policy{MaxIterations: 2}, "t"},
+	}
+
+	for _, v := range vectors {
+		err := v.policy.Check(s)
+		if v.param == "" {
+			if err != nil {
+				t.Errorf("%+v: got %v  want nil", v.policy, err)
+			}
+			continue
+		}
+		if perr, ok := err.(*PolicyError); !ok || perr.Param != v.param {
+			t.Errorf("%+v: got %v  want violation of %s", v.policy, err, v.param)
+		}
+	}
+}
+
+func TestPolicy_Verify(t *testing.T) {
+	password := []byte("password")
+	s, err := HashEncoded(NewContext(ModeArgon2i), password, []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vectors := []struct {
+		policy Policy
+		param  string
+	}{
+		{Policy{}, ""},
+		{Policy{Modes: []Mode{ModeArgon2i}, Versions: []Version{Version13}, MaxMemory: 1 << 12, MaxIterations: 3, MaxLanes: 1, MinSaltLen: 8}, ""},
+		{Policy{Modes: []Mode{ModeArgon2id}}, "mode"},
+		{Policy{Versions: []Version{Version10}}, "v"},
+		{Policy{MaxMemory: 1 << 10}, "m"},
+		{Policy{MaxIterations: 2}, "t"},
+		{Policy{MaxLanes: 1}, ""},
+		{Policy{MinSaltLen: 16}, "salt"},
+	}
+
+	for _, v := range vectors {
+		ok, err := v.policy.VerifyEncoded(s, password)
+		if v.param == "" {
+			if err != nil || !ok {
+				t.Errorf("%+v: got %v, %v  want true", v.policy, ok, err)
+			}
+			if err := v.policy.Check(s); err != nil {
+				t.Errorf("%+v: Check = %v  want nil", v.policy, err)
+			}
+			continue
+		}
+		var perr *PolicyError
+		if ok || !errors.As(err, &perr) || perr.Param != v.param || !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("%+v: got %v, %v  want violation of %s", v.policy, ok, err, v.param)
+		}
+		if err := v.policy.Check(s); !errors.As(err, &perr) || perr.Param != v.param {
+			t.Errorf("%+v: Check = %v  want violation of %s", v.policy, err, v.param)
+		}
+	}
+}
+
+func TestPolicy_Verify_Untrusted(t *testing.T) {
+	// 4 TiB and 2^32-1 passes: rejected before any memory is allocated
+	s := "$argon2id$v=19$m=4294967295,t=4294967295,p=1$c29tZXNhbHQ$Pb9OQA"
+	policy := &Policy{MaxMemory: 1 << 16, MaxIterations: 10, MaxLanes: 4}
+
+	r := new(recorder)
+	withObserver(t, r)
+	ok, err := policy.VerifyEncoded(s, []byte("password"))
+	if ok || err == nil || err.Error() != "argon2: policy violation: m=4294967295 KiB above maximum 65536 KiB" {
+		t.Errorf("got %v, %v", ok, err)
+	}
+	if len(r.events) != 1 || r.events[0].Op != "VerifyEncoded" || r.events[0].Outcome != OutcomeError {
+		t.Errorf("got events %+v", r.events)
+	}
+
+	if _, err := policy.VerifyEncoded("$argon2id$v=19$m=64", []byte("password")); !errors.Is(err, ErrMalformedEncoding) {
+		t.Errorf("malformed: got %v  want %v", err, ErrMalformedEncoding)
+	}
+}
+
+func TestPolicy_DefaultMaxMemory(t *testing.T) {
+	s := "$argon2id$v=19$m=4294967295,t=1,p=1$c29tZXNhbHQ$Pb9OQA"
+
+	// auditing stored hashes checks only the limits that are set
+	if err := (&Policy{MinMemory: 1 << 16}).Check(s); err != nil {
+		t.Errorf("Check: got %v  want nil", err)
+	}
+
+	// verifying always bounds memory
+	var perr *PolicyError
+	if _, err := (&Policy{MinMemory: 1 << 16}).VerifyEncoded(s, []byte("password")); !errors.As(err, &perr) || perr.Param != "m" {
+		t.Errorf("VerifyEncoded: got %v  want violation of m", err)
+	}
+}
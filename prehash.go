@@ -0,0 +1,85 @@
+package argon2
+
+import (
+	"strconv"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Prehash is a digest applied to long passwords before they are hashed, which
+// bounds the input passed to libargon2 without silently truncating it.
+// Passwords longer than the threshold of the context are replaced by their
+// digest; shorter ones are hashed as-is. Both are recorded in encoded strings
+// as the "ph" and "pt" parameters, for example:
+//
+//	$argon2id$v=19$m=65536,t=3,p=4,ph=blake2b-512,pt=128$<salt>$<hash>
+//
+// so that verification pre-hashes exactly the same passwords. Pre-hashing
+// runs after normalization and the length checks.
+type Prehash int
+
+const (
+	PrehashNone       Prehash = iota // passwords are passed to libargon2 in full
+	PrehashBLAKE2b512                // BLAKE2b-512 of passwords above the threshold
+)
+
+// DefaultPrehashThreshold is the length in bytes above which passwords are
+// pre-hashed if the threshold of the context is zero.
+const DefaultPrehashThreshold = 128
+
+// Encoded string parameters naming the pre-hash and its threshold.
+const (
+	prehashParam   = "ph"
+	thresholdParam = "pt"
+)
+
+var prehashNames = map[Prehash]string{
+	PrehashBLAKE2b512: "blake2b-512",
+}
+
+// String returns the name of the pre-hash as recorded in encoded strings, or
+// "none".
+func (p Prehash) String() string {
+	if name, ok := prehashNames[p]; ok {
+		return name
+	}
+	if p == PrehashNone {
+		return "none"
+	}
+	return "Prehash(" + strconv.Itoa(int(p)) + ")"
+}
+
+// parsePrehash parses the value of a ph parameter.
+func parsePrehash(s string) (Prehash, bool) {
+	for p, name := range prehashNames {
+		if s == name {
+			return p, true
+		}
+	}
+	return 0, false
+}
+
+// prehashThreshold returns the effective pre-hash threshold of ctx.
+func (ctx *Context) prehashThreshold() int {
+	if ctx.PrehashThreshold > 0 {
+		return ctx.PrehashThreshold
+	}
+	return DefaultPrehashThreshold
+}
+
+// prehash returns the digest of password if it is longer than the threshold
+// of ctx, or password itself otherwise.
+func (ctx *Context) prehash(password []byte) ([]byte, error) {
+	switch ctx.Prehash {
+	case PrehashNone:
+		return password, nil
+	case PrehashBLAKE2b512:
+		if len(password) <= ctx.prehashThreshold() {
+			return password, nil
+		}
+		d := blake2b.Sum512(password)
+		return d[:], nil
+	default:
+		return nil, ErrIncorrectParameter
+	}
+}
@@ -0,0 +1,92 @@
+package argon2
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+func TestPrehash(t *testing.T) {
+	ctx := NewContext(ModeArgon2id)
+	ctx.Prehash = PrehashBLAKE2b512
+	ctx.PrehashThreshold = 64
+	ctx.SaltLen = 8
+	salt := []byte("somesalt")
+
+	long := bytes.Repeat([]byte("password"), 1<<17) // 1 MiB
+	s, err := HashEncoded(ctx, long, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(s, ",ph=blake2b-512,pt=64$") {
+		t.Errorf("pre-hash not recorded in %q", s)
+	}
+
+	if ok, err := VerifyEncoded(s, long); err != nil || !ok {
+		t.Errorf("VerifyEncoded = %v, %v  want true", ok, err)
+	}
+	wrong := append(append([]byte(nil), long[:len(long)-1]...), 'x')
+	if ok, _ := VerifyEncoded(s, wrong); ok {
+		t.Errorf("VerifyEncoded accepted a password differing in the last byte")
+	}
+
+	dctx, _, _, err := Decode(s)
+	if err != nil || dctx.Prehash != PrehashBLAKE2b512 || dctx.PrehashThreshold != 64 {
+		t.Errorf("Decode = %+v, %v", dctx, err)
+	}
+	if NeedsRehash(ctx, s) {
+		t.Errorf("NeedsRehash = true  want false")
+	}
+	ctx2 := *ctx
+	ctx2.PrehashThreshold = 0 // DefaultPrehashThreshold
+	if !NeedsRehash(&ctx2, s) {
+		t.Errorf("NeedsRehash with other threshold = false  want true")
+	}
+
+	// long passwords are hashed as their digest, short ones as-is
+	plain := NewContext(ModeArgon2id)
+	d := blake2b.Sum512(long)
+	h1, _ := Hash(ctx, long, salt)
+	h2, _ := Hash(plain, d[:], salt)
+	if !bytes.Equal(h1, h2) {
+		t.Errorf("long password not pre-hashed")
+	}
+	short := long[:64]
+	h1, _ = Hash(ctx, short, salt)
+	h2, _ = Hash(plain, short, salt)
+	if !bytes.Equal(h1, h2) {
+		t.Errorf("short password pre-hashed")
+	}
+}
+
+func TestPrehash_Decode(t *testing.T) {
+	const params = "$argon2id$v=19$m=4096,t=3,p=1"
+	const tail = "$c29tZXNhbHQ$THaZx86KeqT+xuygENqvxaYIk3zu4wH0UmqzBL/wrdQ"
+	for _, p := range []string{
+		",ph=blake2b-512",
+		",pt=64",
+		",ph=blake2b-512,pt=0",
+		",ph=sha256,pt=64",
+	} {
+		if _, err := decode(params + p + tail); !errors.Is(err, ErrDecodingFail) {
+			t.Errorf("%s: got %v  want %v", p, err, ErrDecodingFail)
+		}
+	}
+}
+
+func TestPrehash_ClearPassword(t *testing.T) {
+	ctx := NewContext()
+	ctx.Prehash = PrehashBLAKE2b512
+	ctx.Flags = FlagClearPassword
+
+	password := bytes.Repeat([]byte("password"), 32)
+	if _, err := Hash(ctx, password, []byte("somesalt")); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(make([]byte, len(password)), password) {
+		t.Fatalf("password slice is not cleared")
+	}
+}
@@ -0,0 +1,105 @@
+package argon2
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// DefaultSaltLen is the length in bytes of generated salts if the SaltLen of
+// a context is zero.
+const DefaultSaltLen = 16
+
+// A SaltSource generates salts.
+type SaltSource interface {
+	// Salt fills b with a new salt.
+	Salt(b []byte) error
+}
+
+// RandomSaltSource reads salts from crypto/rand. It is used if the
+// SaltSource of a context is nil.
+var RandomSaltSource SaltSource = randomSaltSource{}
+
+type randomSaltSource struct{}
+
+func (randomSaltSource) Salt(b []byte) error {
+	_, err := io.ReadFull(rand.Reader, b)
+	return err
+}
+
+// deterministicSaltSource derives salts from a seed and a counter.
+type deterministicSaltSource struct {
+	mu   sync.Mutex
+	seed []byte
+	n    uint64
+}
+
+// NewDeterministicSaltSource returns a SaltSource that produces the same
+// sequence of salts for the same seed. It is meant for tests that compare
+// encoded hashes; salts must be unpredictable in production.
+func NewDeterministicSaltSource(seed []byte) SaltSource {
+	return &deterministicSaltSource{seed: append([]byte(nil), seed...)}
+}
+
+func (s *deterministicSaltSource) Salt(b []byte) error {
+	s.mu.Lock()
+	n := s.n
+	s.n++
+	s.mu.Unlock()
+
+	// BLAKE2b-512 of seed || n || block, for as many blocks as needed
+	var suffix [16]byte
+	binary.LittleEndian.PutUint64(suffix[:8], n)
+	for block := uint64(0); len(b) > 0; block++ {
+		binary.LittleEndian.PutUint64(suffix[8:], block)
+		sum := blake2b.Sum512(append(append([]byte(nil), s.seed...), suffix[:]...))
+		b = b[copy(b, sum[:]):]
+	}
+	return nil
+}
+
+// saltLen returns the length of salts generated for ctx.
+func (ctx *Context) saltLen() int {
+	if ctx.SaltLen != 0 {
+		return ctx.SaltLen
+	}
+	return DefaultSaltLen
+}
+
+// NewSalt generates a salt of ctx.SaltLen bytes (DefaultSaltLen if zero)
+// from ctx.SaltSource (crypto/rand if nil), as GenerateEncoded does. Use it
+// to hash with Hash or a Hasher and Encode the result.
+func (ctx *Context) NewSalt() ([]byte, error) {
+	n := ctx.saltLen()
+	if n < 0 {
+		return nil, opError("Hash", ErrSaltTooShort)
+	}
+	src := ctx.SaltSource
+	if src == nil {
+		src = RandomSaltSource
+	}
+	salt := make([]byte, n)
+	if err := src.Salt(salt); err != nil {
+		return nil, &OpError{Op: "Hash", Field: "salt", Err: err}
+	}
+	return salt, nil
+}
+
+// GenerateEncoded hashes a password with a new salt of ctx.SaltLen bytes
+// (DefaultSaltLen if zero) from ctx.SaltSource (crypto/rand if nil), and
+// produces an encoded string as HashEncoded does:
+//
+//	s, err := argon2.GenerateEncoded(ctx, password)
+func GenerateEncoded(ctx *Context, password []byte) (string, error) {
+	if ctx == nil {
+		return "", opError("Hash", ErrContext)
+	}
+	salt, err := ctx.NewSalt()
+	if err != nil {
+		return "", opError("Hash", err)
+	}
+	return HashEncoded(ctx, password, salt)
+}
@@ -0,0 +1,115 @@
+package argon2
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+type failingSaltSource struct{}
+
+var errNoEntropy = errors.New("no entropy")
+
+func (failingSaltSource) Salt(b []byte) error {
+	return errNoEntropy
+}
+
+func TestGenerateEncoded(t *testing.T) {
+	ctx := NewContext(ModeArgon2id)
+	password := []byte("password")
+
+	s1, err := GenerateEncoded(ctx, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := GenerateEncoded(ctx, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s1 == s2 {
+		t.Errorf("GenerateEncoded produced the same string twice: %q", s1)
+	}
+	if ok, err := VerifyEncoded(s1, password); err != nil || !ok {
+		t.Errorf("VerifyEncoded = %v, %v  want true", ok, err)
+	}
+	if _, salt, _, _ := Decode(s1); len(salt) != DefaultSaltLen {
+		t.Errorf("got %d-byte salt  want %d", len(salt), DefaultSaltLen)
+	}
+
+	ctx.SaltLen = 32
+	s3, err := GenerateEncoded(ctx, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dctx, salt, _, err := Decode(s3)
+	if err != nil || len(salt) != 32 || dctx.SaltLen != 32 {
+		t.Errorf("Decode = %+v, %d-byte salt, %v  want 32-byte salt", dctx, len(salt), err)
+	}
+	if NeedsRehash(ctx, s3) {
+		t.Errorf("NeedsRehash with 32-byte salt = true  want false")
+	}
+	if !NeedsRehash(ctx, s1) {
+		t.Errorf("NeedsRehash with 16-byte salt = false  want true")
+	}
+}
+
+func TestGenerateEncoded_Error(t *testing.T) {
+	ctx := NewContext()
+	ctx.SaltSource = failingSaltSource{}
+	_, err := GenerateEncoded(ctx, []byte("password"))
+	var oerr *OpError
+	if !errors.Is(err, errNoEntropy) || !errors.As(err, &oerr) || oerr.Field != "salt" {
+		t.Errorf("failing source: got %v", err)
+	}
+
+	ctx = NewContext()
+	ctx.SaltLen = 4
+	if _, err := GenerateEncoded(ctx, []byte("password")); !errors.Is(err, ErrSaltTooShort) {
+		t.Errorf("4-byte salt: got %v  want %v", err, ErrSaltTooShort)
+	}
+	ctx.SaltLen = -1
+	if _, err := GenerateEncoded(ctx, []byte("password")); !errors.Is(err, ErrSaltTooShort) {
+		t.Errorf("negative salt length: got %v  want %v", err, ErrSaltTooShort)
+	}
+	if _, err := GenerateEncoded(nil, []byte("password")); !errors.Is(err, ErrContext) {
+		t.Errorf("nil context: got %v  want %v", err, ErrContext)
+	}
+}
+
+func TestDeterministicSaltSource(t *testing.T) {
+	generate := func(seed string) []string {
+		ctx := NewContext(ModeArgon2id)
+		ctx.SaltSource = NewDeterministicSaltSource([]byte(seed))
+		var out []string
+		for i := 0; i < 3; i++ {
+			s, err := GenerateEncoded(ctx, []byte("password"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, s)
+		}
+		return out
+	}
+
+	a, b, c := generate("seed"), generate("seed"), generate("other")
+	for i := range a {
+		if a[i] != b[i] {
+			t.Errorf("%d: same seed produced %q and %q", i, a[i], b[i])
+		}
+		if a[i] == c[i] {
+			t.Errorf("%d: different seeds produced %q", i, a[i])
+		}
+	}
+	if a[0] == a[1] || a[1] == a[2] {
+		t.Errorf("salts repeat within a sequence: %q", a)
+	}
+
+	// salts longer than one BLAKE2b-512 digest do not repeat blocks
+	salt := make([]byte, 128)
+	if err := NewDeterministicSaltSource(nil).Salt(salt); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(salt[:64], salt[64:]) {
+		t.Errorf("128-byte salt repeats its first 64 bytes")
+	}
+}
@@ -0,0 +1,100 @@
+package argon2
+
+import (
+	"runtime"
+	"sync"
+)
+
+// SecretBytes holds a password or secret outside of the Go heap, so that it
+// is never copied by the garbage collector. On Unix systems the memory is
+// locked into RAM, so it is not written to swap, and surrounded by
+// inaccessible guard pages, so that overruns fault instead of leaking
+// neighbouring data. The contents are wiped by Destroy.
+//
+// Because the memory is outside the Go heap, slices returned by Bytes do not
+// keep s alive. A finalizer therefore only wipes and releases s if Bytes was
+// never called; otherwise s must be destroyed explicitly, or its memory stays
+// allocated until the process exits.
+//
+// Bytes can be passed directly to Hash, HashEncoded, Verify and
+// VerifyEncoded, which do not copy passwords other than to normalize or
+// pre-hash them, and wipe such copies afterwards:
+//
+//	pw, err := argon2.SecretBytesFrom(input) // wipes input
+//	if err != nil {
+//		return err
+//	}
+//	defer pw.Destroy()
+//	ok, err := argon2.VerifyEncoded(s, pw.Bytes())
+type SecretBytes struct {
+	mu  sync.Mutex
+	mem []byte // entire allocation, including guard pages
+	b   []byte
+
+	exposed bool // Bytes was called, so the finalizer must not free mem
+}
+
+// NewSecretBytes allocates a zeroed SecretBytes of length n.
+func NewSecretBytes(n int) (*SecretBytes, error) {
+	if n < 0 {
+		return nil, ErrIncorrectParameter
+	}
+
+	mem, b, err := allocSecret(n)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SecretBytes{mem: mem, b: b}
+	runtime.SetFinalizer(s, (*SecretBytes).finalize)
+	return s, nil
+}
+
+// SecretBytesFrom copies b into a new SecretBytes and wipes b.
+func SecretBytesFrom(b []byte) (*SecretBytes, error) {
+	s, err := NewSecretBytes(len(b))
+	if err != nil {
+		return nil, err
+	}
+	copy(s.b, b)
+	zero(b)
+	return s, nil
+}
+
+// Bytes returns the contents of s, which can be read and written until
+// Destroy is called. It returns nil after Destroy.
+func (s *SecretBytes) Bytes() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exposed = true
+	return s.b
+}
+
+// Len returns the length of s, or 0 after Destroy.
+func (s *SecretBytes) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.b)
+}
+
+// Destroy wipes and releases the memory of s. Slices returned by Bytes must
+// not be used afterwards. It is safe to call Destroy more than once.
+func (s *SecretBytes) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mem == nil {
+		return
+	}
+
+	zero(s.b)
+	freeSecret(s.mem)
+	s.mem, s.b = nil, nil
+	runtime.SetFinalizer(s, nil)
+}
+
+// finalize destroys s, unless a slice from Bytes may still refer to it.
+func (s *SecretBytes) finalize() {
+	if !s.exposed {
+		s.Destroy()
+	}
+}
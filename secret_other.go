@@ -0,0 +1,14 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package argon2
+
+// allocSecret allocates n bytes on the Go heap. Memory is neither locked nor
+// guarded on this platform, but it is still wiped by Destroy.
+func allocSecret(n int) (mem, b []byte, err error) {
+	mem = make([]byte, n)
+	return mem, mem, nil
+}
+
+// freeSecret releases memory returned by allocSecret.
+func freeSecret(mem []byte) {}
@@ -0,0 +1,113 @@
+package argon2
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestSecretBytes(t *testing.T) {
+	input := []byte("somepassword")
+	s, err := SecretBytesFrom(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Destroy()
+
+	if !bytes.Equal(input, make([]byte, len(input))) {
+		t.Errorf("input is not wiped")
+	}
+	if string(s.Bytes()) != "somepassword" || s.Len() != 12 {
+		t.Errorf("Bytes() = %q", s.Bytes())
+	}
+
+	ctx := NewContext()
+	h1, err := Hash(ctx, s.Bytes(), []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, _ := Hash(ctx, []byte("somepassword"), []byte("somesalt"))
+	if !bytes.Equal(h1, h2) {
+		t.Errorf("Hash differs for SecretBytes")
+	}
+
+	encoded, _ := HashEncoded(ctx, []byte("somepassword"), []byte("somesalt"))
+	if ok, err := VerifyEncoded(encoded, s.Bytes()); err != nil || !ok {
+		t.Errorf("VerifyEncoded = %v, %v  want true", ok, err)
+	}
+
+	s.Destroy()
+	s.Destroy()
+	if s.Bytes() != nil || s.Len() != 0 {
+		t.Errorf("Bytes() after Destroy = %q", s.Bytes())
+	}
+}
+
+func TestSecretBytes_Finalizer(t *testing.T) {
+	input := []byte("password")
+	s, err := SecretBytesFrom(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := s.Bytes()
+	s = nil
+
+	// the slice does not keep s alive, so the finalizer must not free it
+	runtime.GC()
+	runtime.GC()
+	if string(b) != "password" {
+		t.Fatalf("Bytes() after GC = %q  want %q", b, "password")
+	}
+	ctx := NewContext()
+	h1, err := Hash(ctx, b, []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, _ := Hash(ctx, []byte("password"), []byte("somesalt"))
+	if !bytes.Equal(h1, h2) {
+		t.Errorf("Hash after GC differs")
+	}
+}
+
+func TestSecretBytes_Sizes(t *testing.T) {
+	for _, n := range []int{0, 1, 4095, 4096, 4097, 1 << 16} {
+		s, err := NewSecretBytes(n)
+		if err != nil {
+			t.Fatalf("NewSecretBytes(%d): %v", n, err)
+		}
+		b := s.Bytes()
+		if len(b) != n || !bytes.Equal(b, make([]byte, n)) {
+			t.Errorf("NewSecretBytes(%d): got %d bytes", n, len(b))
+		}
+		for i := range b {
+			b[i] = 0xff
+		}
+		s.Destroy()
+	}
+
+	if _, err := NewSecretBytes(-1); !errors.Is(err, ErrIncorrectParameter) {
+		t.Errorf("NewSecretBytes(-1): got %v  want %v", err, ErrIncorrectParameter)
+	}
+}
+
+func TestPrepare_WipesCopies(t *testing.T) {
+	ctx := NewContext()
+	ctx.Normalization = NormalizationNFKC
+	password := []byte("ﬁsh")
+
+	p, err := ctx.prepare(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p) != "fish" {
+		t.Fatalf("prepare = %q", p)
+	}
+	release(p, password)
+	if !bytes.Equal(p, make([]byte, len(p))) {
+		t.Errorf("normalized copy is not wiped")
+	}
+	if string(password) != "ﬁsh" {
+		t.Errorf("password modified without FlagClearPassword")
+	}
+}
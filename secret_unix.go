@@ -0,0 +1,45 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package argon2
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// allocSecret maps n bytes of locked memory between two guard pages. The
+// returned slice ends at the second guard page, so that writes past its end
+// fault.
+func allocSecret(n int) (mem, b []byte, err error) {
+	page := unix.Getpagesize()
+	size := (n + page - 1) / page * page
+
+	mem, err = unix.Mmap(-1, 0, size+2*page, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANON)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := mem[page : page+size]
+	err = unix.Mprotect(mem[:page], unix.PROT_NONE)
+	if err == nil {
+		err = unix.Mprotect(mem[page+size:], unix.PROT_NONE)
+	}
+	if err == nil && size > 0 {
+		err = unix.Mlock(data)
+	}
+	if err != nil {
+		unix.Munmap(mem)
+		return nil, nil, err
+	}
+
+	return mem, data[size-n:], nil
+}
+
+// freeSecret unlocks and unmaps memory returned by allocSecret.
+func freeSecret(mem []byte) {
+	page := unix.Getpagesize()
+	if data := mem[page : len(mem)-page]; len(data) > 0 {
+		unix.Munlock(data)
+	}
+	unix.Munmap(mem)
+}
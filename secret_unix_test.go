@@ -0,0 +1,29 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package argon2
+
+import (
+	"runtime/debug"
+	"testing"
+	"unsafe"
+)
+
+func TestSecretBytes_GuardPage(t *testing.T) {
+	s, err := NewSecretBytes(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Destroy()
+
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if recover() == nil {
+			t.Errorf("write past the end did not fault")
+		}
+	}()
+
+	b := s.Bytes()
+	p := (*byte)(unsafe.Pointer(uintptr(unsafe.Pointer(&b[len(b)-1])) + 1))
+	*p = 1
+}
@@ -0,0 +1,267 @@
+package argon2
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Scheme verifies passwords against hashes of a single encoded format.
+type Scheme interface {
+	Verify(encoded string, password []byte) (bool, error)
+}
+
+// SchemeFunc adapts an ordinary function to the Scheme interface.
+type SchemeFunc func(encoded string, password []byte) (bool, error)
+
+// Verify calls f(encoded, password).
+func (f SchemeFunc) Verify(encoded string, password []byte) (bool, error) {
+	return f(encoded, password)
+}
+
+// Verifier verifies passwords against hashes of several schemes, which makes
+// it possible to migrate a table of legacy hashes to Argon2 as users log in.
+// Argon2 encoded strings are verified with Policy.VerifyEncodedWith and the
+// context of the Verifier, so that hashes computed with its Secret verify, or
+// like VerifyWrapped if they were produced by WrapLegacy; any other hash is
+// dispatched to the Scheme registered for the longest matching prefix.
+//
+// The cost of a hash is read from the hash itself, so a tampered row could
+// make verification allocate any amount of memory or run for hours. Hashes
+// whose cost exceeds Policy or the limits below fail with a *PolicyError
+// before any hashing; the limits may be changed before the Verifier is used.
+type Verifier struct {
+	// Policy is enforced on Argon2 hashes, wrapped or not. If nil, only
+	// DefaultMaxMemory is enforced.
+	Policy *Policy
+
+	MaxBcryptCost    int // maximum bcrypt cost, including that of wrapped hashes
+	MaxScryptLogN    int // maximum log2 of the scrypt cost N
+	MaxScryptR       int // maximum scrypt block size r
+	MaxScryptP       int // maximum scrypt parallelism p
+	MaxPBKDF2Rounds  int // maximum number of PBKDF2 rounds
+	MaxLegacyHashLen int // maximum length in bytes of scrypt and PBKDF2 hashes
+
+	ctx     *Context
+	schemes map[string]Scheme
+}
+
+// Default limits of a Verifier. scrypt with ln=20 and r=16 uses 2 GiB. PBKDF2
+// runs its rounds once per block of the hash, so the hash length is limited as
+// well. The Policy of a new Verifier limits Argon2 hashes to DefaultMaxMemory,
+// DefaultMaxIterations and DefaultMaxLanes.
+const (
+	DefaultMaxIterations    = 32
+	DefaultMaxLanes         = 64
+	DefaultMaxBcryptCost    = 16
+	DefaultMaxScryptLogN    = 20
+	DefaultMaxScryptR       = 16
+	DefaultMaxScryptP       = 16
+	DefaultMaxPBKDF2Rounds  = 10000000
+	DefaultMaxLegacyHashLen = 64
+)
+
+// NewVerifier returns a Verifier that reports hashes as needing a rehash
+// unless they are Argon2 hashes computed with the parameters in ctx. If ctx is
+// nil, Argon2 hashes never need a rehash. It has schemes registered for bcrypt
+// ($2a$, $2b$, $2y$), scrypt ($scrypt$) and PBKDF2 ($pbkdf2$,
+// $pbkdf2-sha256$, $pbkdf2-sha512$) in the formats used by passlib.
+func NewVerifier(ctx *Context) *Verifier {
+	v := &Verifier{
+		Policy: &Policy{
+			MaxIterations: DefaultMaxIterations,
+			MaxLanes:      DefaultMaxLanes,
+		},
+		MaxBcryptCost:    DefaultMaxBcryptCost,
+		MaxScryptLogN:    DefaultMaxScryptLogN,
+		MaxScryptR:       DefaultMaxScryptR,
+		MaxScryptP:       DefaultMaxScryptP,
+		MaxPBKDF2Rounds:  DefaultMaxPBKDF2Rounds,
+		MaxLegacyHashLen: DefaultMaxLegacyHashLen,
+		ctx:              ctx,
+		schemes:          make(map[string]Scheme),
+	}
+
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		v.Register(prefix, SchemeFunc(v.verifyBcrypt))
+	}
+	v.Register("$scrypt$", SchemeFunc(v.verifyScrypt))
+	v.Register("$pbkdf2$", v.pbkdf2Scheme(sha1.New))
+	v.Register("$pbkdf2-sha256$", v.pbkdf2Scheme(sha256.New))
+	v.Register("$pbkdf2-sha512$", v.pbkdf2Scheme(sha512.New))
+	return v
+}
+
+// Register adds a scheme for hashes starting with prefix, replacing any
+// scheme previously registered for the same prefix.
+func (v *Verifier) Register(prefix string, s Scheme) {
+	v.schemes[prefix] = s
+}
+
+// Verify verifies a stored hash against a plaintext password. If the
+// password matches, rehash reports whether the stored hash should be replaced
+// with a fresh HashEncoded result, either because it uses (or wraps) a legacy
+// scheme or because its Argon2 parameters differ from those of the Verifier.
+func (v *Verifier) Verify(encoded string, password []byte) (ok, rehash bool, err error) {
+	if len(password) == 0 {
+		return false, false, ErrPassword
+	}
+
+	if strings.HasPrefix(encoded, "$argon2") {
+		if wrapped(encoded) {
+			ok, err = verifyWrapped(v.Policy, v.MaxBcryptCost, encoded, password)
+			return ok, ok, err
+		}
+		ok, err = v.Policy.VerifyEncodedWith(v.ctx, encoded, password)
+		return ok, ok && v.ctx != nil && NeedsRehash(v.ctx, encoded), err
+	}
+
+	s := v.lookup(encoded)
+	if s == nil {
+		return false, false, ErrUnknownScheme
+	}
+	ok, err = s.Verify(encoded, password)
+	return ok, ok, err
+}
+
+// lookup returns the scheme registered for the longest prefix of encoded.
+func (v *Verifier) lookup(encoded string) Scheme {
+	var match string
+	for prefix := range v.schemes {
+		if strings.HasPrefix(encoded, prefix) && len(prefix) > len(match) {
+			match = prefix
+		}
+	}
+	return v.schemes[match]
+}
+
+func (v *Verifier) verifyBcrypt(encoded string, password []byte) (bool, error) {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return false, err
+	}
+	if err := checkMax("cost", cost, v.MaxBcryptCost); err != nil {
+		return false, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(encoded), password)
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// verifyScrypt verifies hashes of the form
+// $scrypt$ln=<log2 N>,r=<r>,p=<p>$<salt>$<hash>.
+func (v *Verifier) verifyScrypt(encoded string, password []byte) (bool, error) {
+	fields := strings.Split(encoded, "$")
+	if len(fields) != 5 {
+		return false, ErrDecodingFail
+	}
+
+	var ln, r, p int
+	params := strings.Split(fields[2], ",")
+	if len(params) != 3 {
+		return false, ErrDecodingFail
+	}
+	for i, dst := range []*int{&ln, &r, &p} {
+		kv := strings.SplitN(params[i], "=", 2)
+		if len(kv) != 2 || kv[0] != []string{"ln", "r", "p"}[i] {
+			return false, ErrDecodingFail
+		}
+		n, err := parseUint(kv[1])
+		if err != nil {
+			return false, err
+		}
+		*dst = n
+	}
+	if ln < 1 || r < 1 || p < 1 {
+		return false, ErrDecodingFail
+	}
+	if err := checkMax("ln", ln, v.MaxScryptLogN); err != nil {
+		return false, err
+	}
+	if err := checkMax("r", r, v.MaxScryptR); err != nil {
+		return false, err
+	}
+	if err := checkMax("p", p, v.MaxScryptP); err != nil {
+		return false, err
+	}
+
+	salt, sum, err := decodeLegacySaltHash(fields[3], fields[4])
+	if err != nil {
+		return false, err
+	}
+	if err := checkMax("hash", len(sum), v.MaxLegacyHashLen); err != nil {
+		return false, err
+	}
+
+	sum2, err := scrypt.Key(password, salt, 1<<uint(ln), r, p, len(sum))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(sum, sum2) == 1, nil
+}
+
+// pbkdf2Scheme returns a scheme that verifies hashes of the form
+// $pbkdf2[-<digest>]$<rounds>$<salt>$<hash>.
+func (v *Verifier) pbkdf2Scheme(h func() hash.Hash) Scheme {
+	return SchemeFunc(func(encoded string, password []byte) (bool, error) {
+		fields := strings.Split(encoded, "$")
+		if len(fields) != 5 {
+			return false, ErrDecodingFail
+		}
+
+		rounds, err := strconv.Atoi(fields[2])
+		if err != nil || rounds < 1 {
+			return false, ErrDecodingFail
+		}
+		if err := checkMax("rounds", rounds, v.MaxPBKDF2Rounds); err != nil {
+			return false, err
+		}
+
+		salt, sum, err := decodeLegacySaltHash(fields[3], fields[4])
+		if err != nil {
+			return false, err
+		}
+		if err := checkMax("hash", len(sum), v.MaxLegacyHashLen); err != nil {
+			return false, err
+		}
+
+		sum2 := pbkdf2.Key(password, salt, rounds, len(sum), h)
+		return subtle.ConstantTimeCompare(sum, sum2) == 1, nil
+	})
+}
+
+// checkMax returns a *PolicyError if the parameter param of a legacy hash
+// exceeds max.
+func checkMax(param string, value, max int) error {
+	if value > max {
+		return &PolicyError{param, fmt.Sprintf("%s=%d above maximum %d", param, value, max)}
+	}
+	return nil
+}
+
+// decodeLegacySaltHash decodes the salt and hash fields of passlib-style
+// strings, which use unpadded base64 with '.' in place of '+'.
+func decodeLegacySaltHash(salt, sum string) ([]byte, []byte, error) {
+	s, err := base64.RawStdEncoding.DecodeString(strings.Replace(salt, ".", "+", -1))
+	if err != nil || len(s) == 0 {
+		return nil, nil, ErrDecodingFail
+	}
+	h, err := base64.RawStdEncoding.DecodeString(strings.Replace(sum, ".", "+", -1))
+	if err != nil || len(h) == 0 {
+		return nil, nil, ErrDecodingFail
+	}
+	return s, h, nil
+}
@@ -0,0 +1,155 @@
+package argon2
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestVerifier(t *testing.T) {
+	ctx := NewContext(ModeArgon2id)
+	ctx.SaltLen = 8
+	v := NewVerifier(ctx)
+
+	current, err := HashEncoded(ctx, []byte("password"), []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outdated, err := HashEncoded(NewContext(ModeArgon2i), []byte("password"), []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcrypted, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vectors := []struct {
+		encoded  string
+		password string
+		rehash   bool
+	}{
+		{current, "password", false},
+		{outdated, "password", true},
+		{string(bcrypted), "password", true},
+		// RFC 7914, section 11
+		{"$pbkdf2-sha256$1$c2FsdA$VawEblbjCJ/sFpHCJUS2BflBhSFt3gRl5oudV8INrLxJypzM8Xm2RZkWZLOdd.8xfHG4RbHjC9UJESBB06GXgw", "passwd", true},
+		// passlib documentation
+		{"$scrypt$ln=16,r=8,p=1$aM15713r3Xsvxbi31lqr1Q$nFNh2CVHVjNldFVKDHDlm4CbdRSCdEBsjjJxD+iCs5E", "password", true},
+	}
+
+	for _, vec := range vectors {
+		ok, rehash, err := v.Verify(vec.encoded, []byte(vec.password))
+		if err != nil {
+			t.Errorf("Verify(%q): %s", vec.encoded, err)
+			continue
+		}
+		if !ok || rehash != vec.rehash {
+			t.Errorf("Verify(%q) = %t, %t  want true, %t", vec.encoded, ok, rehash, vec.rehash)
+		}
+
+		ok, rehash, err = v.Verify(vec.encoded, []byte("wrong"))
+		if err != nil {
+			t.Errorf("Verify(%q): %s", vec.encoded, err)
+			continue
+		}
+		if ok || rehash {
+			t.Errorf("Verify(%q, badpw) = %t, %t  want false, false", vec.encoded, ok, rehash)
+		}
+	}
+}
+
+func TestVerifier_UnknownScheme(t *testing.T) {
+	v := NewVerifier(NewContext())
+	_, _, err := v.Verify("$1$saltsalt$qjXMvbEw8oaL.CzflDugX/", []byte("password"))
+	if !errors.Is(err, ErrUnknownScheme) {
+		t.Errorf("got %v  want %v", err, ErrUnknownScheme)
+	}
+}
+
+func TestVerifier_Limits(t *testing.T) {
+	v := NewVerifier(NewContext())
+
+	bcrypted, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := WrapLegacy(NewContext(ModeArgon2id), LegacyBcrypt,
+		strings.Replace(string(bcrypted), "$04$", "$31$", 1), []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vectors := []struct {
+		encoded string
+		param   string
+	}{
+		{"$argon2id$v=19$m=65536,t=1000,p=1$c29tZXNhbHQ$c29tZWhhc2g", "t"},
+		{"$argon2id$v=19$m=65536,t=1,p=1000$c29tZXNhbHQ$c29tZWhhc2g", "p"},
+		{"$2a$31$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy", "cost"},
+		{wrapped, "cost"},
+		// 128 TiB
+		{"$scrypt$ln=40,r=1,p=1$aM15713r3Xsvxbi31lqr1Q$nFNh2CVHVjNldFVKDHDlm4CbdRSCdEBsjjJxD+iCs5E", "ln"},
+		{"$scrypt$ln=10,r=1024,p=1$aM15713r3Xsvxbi31lqr1Q$nFNh2CVHVjNldFVKDHDlm4CbdRSCdEBsjjJxD+iCs5E", "r"},
+		{"$scrypt$ln=10,r=8,p=4096$aM15713r3Xsvxbi31lqr1Q$nFNh2CVHVjNldFVKDHDlm4CbdRSCdEBsjjJxD+iCs5E", "p"},
+		{"$pbkdf2-sha256$2000000000$c2FsdA$VawEblbjCJ/sFpHCJUS2BflBhSFt3gRl5oudV8INrLxJypzM8Xm2RZkWZLOdd.8xfHG4RbHjC9UJESBB06GXgw", "rounds"},
+		// 30000 bytes, so 938 blocks of 1000000 rounds each
+		{"$pbkdf2-sha256$1000000$c29tZXNhbHQ$" + strings.Repeat("A", 40000), "hash"},
+	}
+	for _, vec := range vectors {
+		ok, _, err := v.Verify(vec.encoded, []byte("password"))
+		var perr *PolicyError
+		if ok || !errors.As(err, &perr) || perr.Param != vec.param || !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("Verify(%q) = %t, %v  want violation of %s", vec.encoded, ok, err, vec.param)
+		}
+	}
+
+	// limits may be lowered
+	v.MaxScryptLogN = 15
+	s := "$scrypt$ln=16,r=8,p=1$aM15713r3Xsvxbi31lqr1Q$nFNh2CVHVjNldFVKDHDlm4CbdRSCdEBsjjJxD+iCs5E"
+	if _, _, err := v.Verify(s, []byte("password")); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Verify with MaxScryptLogN 15: got %v  want %v", err, ErrInvalidParams)
+	}
+
+	if _, _, err := v.Verify("$scrypt$ln=0,r=8,p=1$aM15713r3Xsvxbi31lqr1Q$nFNh2CVHVjNldFVKDHDlm4CbdRSCdEBsjjJxD+iCs5E", []byte("password")); !errors.Is(err, ErrMalformedEncoding) {
+		t.Errorf("ln=0: got %v  want %v", err, ErrMalformedEncoding)
+	}
+}
+
+func TestVerifier_NilContext(t *testing.T) {
+	v := NewVerifier(nil)
+	s, err := HashEncoded(NewContext(ModeArgon2i), []byte("password"), []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, rehash, err := v.Verify(s, []byte("password")); err != nil || !ok || rehash {
+		t.Errorf("Verify = %t, %t, %v  want true, false, nil", ok, rehash, err)
+	}
+}
+
+func TestVerifier_Secret(t *testing.T) {
+	ctx := NewContext(ModeArgon2id)
+	ctx.Secret = []byte("pepper")
+	ctx.SaltLen = 8
+	v := NewVerifier(ctx)
+
+	hash, err := Hash(ctx, []byte("password"), []byte("somesalt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, keyID := range []string{"", "k1"} {
+		ctx.KeyID = []byte(keyID)
+		s, err := Encode(ctx, []byte("somesalt"), hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, rehash, err := v.Verify(s, []byte("password")); err != nil || !ok || rehash {
+			t.Errorf("Verify(%q) = %t, %t, %v  want true, false, nil", s, ok, rehash, err)
+		}
+		if ok, _, err := v.Verify(s, []byte("wrong")); err != nil || ok {
+			t.Errorf("Verify(%q, wrong) = %t, %v  want false, nil", s, ok, err)
+		}
+	}
+}